@@ -0,0 +1,295 @@
+// Package apiserver builds, launches, health-checks and stops the contacts
+// API on behalf of a test suite, so every run can start from a fresh
+// instance with an empty store and next_id back at 1.
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cucumber/godog"
+
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/refapi"
+)
+
+// ReadyTimeout bounds how long Start waits for GET /records to answer.
+var ReadyTimeout = 30 * time.Second
+
+type Server struct {
+	cfg     config.Config
+	port    int
+	logPath string
+	logFile *os.File
+
+	mu          sync.Mutex
+	cmd         *exec.Cmd
+	exited      chan struct{}
+	container   string
+	reference   *httptest.Server
+	handler     *refapi.Handler
+	lastFeature string
+}
+
+// Launch starts the API described by cfg and returns the server together
+// with a copy of cfg whose BaseURL points at it. When cfg.Launch is empty it
+// returns a nil server and cfg unchanged; all Server methods accept nil.
+func Launch(cfg config.Config) (*Server, config.Config, error) {
+	if cfg.Launch == config.LaunchNone {
+		return nil, cfg, nil
+	}
+	s := &Server{cfg: cfg}
+
+	if cfg.Launch == config.LaunchBinary {
+		if cfg.Build {
+			dir, err := sourceDir()
+			if err != nil {
+				return nil, cfg, err
+			}
+			if cfg.Binary, err = Build(dir); err != nil {
+				return nil, cfg, err
+			}
+		}
+		binary, err := resolveBinary(cfg.Binary)
+		if err != nil {
+			return nil, cfg, err
+		}
+		s.cfg.Binary = binary
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, cfg, fmt.Errorf("apiserver: failed to find a free port: %v", err)
+	}
+	s.port = port
+
+	s.logPath = cfg.LogFile
+	if s.logPath == "" {
+		f, err := os.CreateTemp("", "contacts-api-*.log")
+		if err != nil {
+			return nil, cfg, fmt.Errorf("apiserver: failed to create log file: %v", err)
+		}
+		s.logFile = f
+		s.logPath = f.Name()
+	} else {
+		f, err := os.Create(s.logPath)
+		if err != nil {
+			return nil, cfg, fmt.Errorf("apiserver: failed to create log file: %v", err)
+		}
+		s.logFile = f
+	}
+
+	if err := s.start(); err != nil {
+		s.logFile.Close()
+		return nil, cfg, err
+	}
+	s.cfg.BaseURL = s.URL()
+	return s, s.cfg, nil
+}
+
+// URL is the base URL of the running API.
+func (s *Server) URL() string {
+	if s.reference != nil {
+		return s.reference.URL
+	}
+	return fmt.Sprintf("http://127.0.0.1:%d", s.port)
+}
+
+// LogPath is the file receiving the API's stdout and stderr.
+func (s *Server) LogPath() string {
+	if s == nil {
+		return ""
+	}
+	return s.logPath
+}
+
+// Restart stops the API and starts it again on the same port.
+func (s *Server) Restart() error {
+	if s == nil {
+		return nil
+	}
+	s.stop()
+	return s.start()
+}
+
+// Stop terminates the API and closes its log.
+func (s *Server) Stop() error {
+	if s == nil {
+		return nil
+	}
+	err := s.stop()
+	if s.reference != nil {
+		s.reference.Close()
+	}
+	s.logFile.Close()
+	return err
+}
+
+// InstallHooks restarts the API before each feature or scenario according
+// to the configured restart policy.
+func (s *Server) InstallHooks(ctx *godog.ScenarioContext) {
+	if s == nil || s.cfg.Restart == config.RestartSuite {
+		return
+	}
+	ctx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+		s.mu.Lock()
+		restart := s.cfg.Restart == config.RestartScenario || sc.Uri != s.lastFeature
+		s.lastFeature = sc.Uri
+		s.mu.Unlock()
+		if !restart {
+			return ctx, nil
+		}
+		return ctx, s.Restart()
+	})
+}
+
+func (s *Server) start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(s.logFile, "--- starting API on port %d at %s\n", s.port, time.Now().Format(time.RFC3339))
+
+	switch s.cfg.Launch {
+	case config.LaunchReference:
+		// Restarting the reference only needs fresh state; the listener
+		// and therefore the URL stay the same.
+		if s.reference == nil {
+			s.handler = refapi.NewHandler()
+			s.handler.Log = s.logFile
+			s.reference = httptest.NewUnstartedServer(s.handler)
+			l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", s.port))
+			if err != nil {
+				return fmt.Errorf("apiserver: failed to listen on port %d: %v", s.port, err)
+			}
+			s.reference.Listener = l
+			s.reference.Start()
+		} else {
+			s.handler.Restart()
+		}
+		return nil
+	case config.LaunchBinary:
+		s.cmd = exec.Command(s.cfg.Binary, strconv.Itoa(s.port))
+		s.cmd.Dir = filepath.Dir(s.cfg.Binary)
+		s.cmd.Env = append(os.Environ(), "PORT="+strconv.Itoa(s.port))
+	case config.LaunchDocker:
+		s.container = fmt.Sprintf("contacts-api-%d-%d", os.Getpid(), s.port)
+		s.cmd = exec.Command("docker", "run", "--rm", "--name", s.container,
+			"-p", fmt.Sprintf("%d:8080", s.port), s.cfg.Image)
+	}
+	s.cmd.Stdout = s.logFile
+	s.cmd.Stderr = s.logFile
+	if err := s.cmd.Start(); err != nil {
+		return fmt.Errorf("apiserver: failed to start %s: %v", s.cmd.Path, err)
+	}
+
+	s.exited = make(chan struct{})
+	go func(cmd *exec.Cmd, exited chan struct{}) {
+		cmd.Wait()
+		close(exited)
+	}(s.cmd, s.exited)
+
+	if err := s.waitReady(); err != nil {
+		s.kill()
+		return err
+	}
+	return nil
+}
+
+func (s *Server) waitReady() error {
+	client := &http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(ReadyTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-s.exited:
+			return fmt.Errorf("apiserver: API exited before becoming ready, see %s", s.logPath)
+		default:
+		}
+		resp, err := client.Get(s.URL() + "/records")
+		if err == nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if s.cfg.Launch == config.LaunchBinary {
+		// A binary built before main.cpp took its port from argv[1] or
+		// $PORT still listens on 8080.
+		return fmt.Errorf("apiserver: GET /records did not answer on port %d within %s, see %s; if %s predates port selection, rebuild it or set %s=1",
+			s.port, ReadyTimeout, s.logPath, s.cfg.Binary, config.EnvBuild)
+	}
+	return fmt.Errorf("apiserver: GET /records did not answer within %s, see %s", ReadyTimeout, s.logPath)
+}
+
+func (s *Server) stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kill()
+}
+
+// kill must be called with s.mu held.
+func (s *Server) kill() error {
+	if s.reference != nil {
+		return nil
+	}
+	if s.cmd == nil || s.cmd.Process == nil {
+		return nil
+	}
+	if s.container != "" {
+		exec.Command("docker", "stop", s.container).Run()
+	}
+	s.cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-s.exited:
+	case <-time.After(5 * time.Second):
+		s.cmd.Process.Kill()
+		<-s.exited
+	}
+	s.cmd = nil
+	return nil
+}
+
+// resolveBinary returns an absolute path to the api binary. An empty path
+// means "the api file next to main.cpp".
+func resolveBinary(path string) (string, error) {
+	if path == "" {
+		dir, err := sourceDir()
+		if err != nil {
+			return "", fmt.Errorf("%v; set %s", err, config.EnvBinary)
+		}
+		path = filepath.Join(dir, "api")
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("apiserver: api binary %s not found; build it first (see README) or set %s=1", abs, config.EnvBuild)
+	}
+	if info.IsDir() || info.Mode()&0o111 == 0 {
+		return "", fmt.Errorf("apiserver: %s is not an executable", abs)
+	}
+	return abs, nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
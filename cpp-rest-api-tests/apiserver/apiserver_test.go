@@ -0,0 +1,191 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+
+	"cpp-rest-api-tests/config"
+)
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestLaunchNothing(t *testing.T) {
+	cfg := config.Config{BaseURL: "http://localhost:8080"}
+	s, got, err := Launch(cfg)
+	if err != nil || s != nil || got.BaseURL != cfg.BaseURL {
+		t.Fatalf("Launch without a launch mode: %v, %v, %+v", s, err, got)
+	}
+	// A nil server is a no-op.
+	if err := s.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if s.LogPath() != "" {
+		t.Fatalf("log path %q", s.LogPath())
+	}
+}
+
+func TestReferenceLaunchRestartStop(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "api.log")
+	s, cfg, err := Launch(config.Config{Launch: config.LaunchReference, LogFile: logPath, Restart: config.RestartSuite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BaseURL != s.URL() || !strings.HasPrefix(cfg.BaseURL, "http://127.0.0.1:") {
+		t.Fatalf("base URL %q, server at %q", cfg.BaseURL, s.URL())
+	}
+	if status, body := get(t, cfg.BaseURL+"/records"); status != http.StatusOK || strings.TrimSpace(body) != "[]" {
+		t.Fatalf("GET /records: %d %s", status, body)
+	}
+	resp, err := http.Post(cfg.BaseURL+"/records", "application/json", strings.NewReader(`{"first_name":"Ada"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if err := s.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	if s.URL() != cfg.BaseURL {
+		t.Fatalf("restart moved the API from %s to %s", cfg.BaseURL, s.URL())
+	}
+	if status, body := get(t, cfg.BaseURL+"/records"); status != http.StatusOK || strings.TrimSpace(body) != "[]" {
+		t.Fatalf("store not empty after restart: %d %s", status, body)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := http.Get(cfg.BaseURL + "/records"); err == nil {
+		t.Fatal("API still answers after Stop")
+	}
+	log, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(log), "--- starting API"); n != 2 {
+		t.Fatalf("log shows %d starts, want 2:\n%s", n, log)
+	}
+}
+
+func TestLaunchMissingBinary(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "api")
+	_, _, err := Launch(config.Config{Launch: config.LaunchBinary, Binary: missing})
+	if err == nil || !strings.Contains(err.Error(), "not found") || !strings.Contains(err.Error(), config.EnvBuild) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// script writes an executable shell script standing in for the api binary.
+func script(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "api")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBinaryExitingEarly(t *testing.T) {
+	binary := script(t, `echo "port $1 env $PORT"; exit 1`)
+	logPath := filepath.Join(t.TempDir(), "api.log")
+	_, _, err := Launch(config.Config{Launch: config.LaunchBinary, Binary: binary, LogFile: logPath})
+	if err == nil || !strings.Contains(err.Error(), "exited before becoming ready") {
+		t.Fatalf("err = %v", err)
+	}
+	log, _ := os.ReadFile(logPath)
+	var port string
+	for _, line := range strings.Split(string(log), "\n") {
+		if strings.HasPrefix(line, "port ") {
+			port = line
+		}
+	}
+	if f := strings.Fields(port); len(f) != 4 || f[1] != f[3] || f[1] == "8080" {
+		t.Fatalf("binary was not given a free port as argv[1] and $PORT:\n%s", log)
+	}
+}
+
+// A binary that never answers on the port it was given, like an api built
+// before main.cpp read one, fails with a hint to rebuild.
+func TestBinaryNeverReady(t *testing.T) {
+	defer func(d time.Duration) { ReadyTimeout = d }(ReadyTimeout)
+	ReadyTimeout = 300 * time.Millisecond
+	binary := script(t, "exec sleep 30")
+	_, _, err := Launch(config.Config{Launch: config.LaunchBinary, Binary: binary, LogFile: filepath.Join(t.TempDir(), "api.log")})
+	if err == nil || !strings.Contains(err.Error(), "did not answer") || !strings.Contains(err.Error(), "rebuild it") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// Two features of two scenarios each create a contact and expect to be
+// alone in the store, which only a restart before each of them gives.
+func TestRestartPolicies(t *testing.T) {
+	scenarios := "Scenario: first\n  Given a contact is created\n  Then the store holds 1 contact\n" +
+		"Scenario: second\n  Given a contact is created\n  Then the store holds 1 contact\n"
+	features := []godog.Feature{
+		{Name: "a.feature", Contents: []byte("Feature: a\n" + scenarios)},
+		{Name: "b.feature", Contents: []byte("Feature: b\n" + scenarios)},
+	}
+	for policy, failures := range map[string]int{
+		config.RestartScenario: 0,
+		config.RestartFeature:  2,
+		config.RestartSuite:    3,
+	} {
+		t.Run(policy, func(t *testing.T) {
+			s, cfg, err := Launch(config.Config{Launch: config.LaunchReference, Restart: policy, LogFile: filepath.Join(t.TempDir(), "api.log")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Stop()
+			failed := 0
+			godog.TestSuite{
+				ScenarioInitializer: func(ctx *godog.ScenarioContext) {
+					s.InstallHooks(ctx)
+					ctx.Step(`^a contact is created$`, func() error {
+						resp, err := http.Post(cfg.BaseURL+"/records", "application/json", strings.NewReader(`{"first_name":"Ada"}`))
+						if err != nil {
+							return err
+						}
+						return resp.Body.Close()
+					})
+					ctx.Step(`^the store holds (\d+) contacts?$`, func(want int) error {
+						_, body := get(t, cfg.BaseURL+"/records")
+						var records []json.RawMessage
+						if err := json.Unmarshal([]byte(body), &records); err != nil {
+							return err
+						}
+						if len(records) != want {
+							failed++
+							return fmt.Errorf("%d contacts, want %d", len(records), want)
+						}
+						return nil
+					})
+				},
+				Options: &godog.Options{Format: "progress", Output: io.Discard, FeatureContents: features, Strict: true},
+			}.Run()
+			if failed != failures {
+				t.Fatalf("%d scenarios found other contacts, want %d", failed, failures)
+			}
+		})
+	}
+}
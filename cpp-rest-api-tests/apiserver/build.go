@@ -0,0 +1,49 @@
+package apiserver
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Build compiles main.cpp in dir into dir/api using the same g++ invocation
+// as the README. HOMEBREW_PREFIX adds the matching -I/-L paths; CXXFLAGS and
+// LDFLAGS are appended verbatim.
+func Build(dir string) (string, error) {
+	output := filepath.Join(dir, "api")
+	args := []string{"-std=c++17", "main.cpp", "-o", output, "-lpistache", "-lpthread"}
+	if prefix := os.Getenv("HOMEBREW_PREFIX"); prefix != "" {
+		args = append(args, "-I"+filepath.Join(prefix, "include"), "-L"+filepath.Join(prefix, "lib"))
+	}
+	args = append(args, strings.Fields(os.Getenv("CXXFLAGS"))...)
+	args = append(args, strings.Fields(os.Getenv("LDFLAGS"))...)
+
+	cmd := exec.Command("g++", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("apiserver: g++ %s failed: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return output, nil
+}
+
+// sourceDir finds the directory holding main.cpp, searching upwards from the
+// working directory so both the module root and its sub-packages find it.
+func sourceDir() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "main.cpp")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("apiserver: no main.cpp found above the working directory")
+		}
+		dir = parent
+	}
+}
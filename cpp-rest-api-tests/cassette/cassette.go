@@ -0,0 +1,75 @@
+// Package cassette records API traffic passing through a reverse proxy and
+// replays it against another server as a regression test.
+//
+// A cassette is a JSON file listing request/response pairs in the order
+// they happened. Replay sends the requests again and compares each answer
+// with the recorded one. IDs are normalized: the ID a replayed create is
+// given is mapped to the one it was given while recording, so a server
+// whose next_id has drifted still matches.
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Version is written to every cassette so that the format can change.
+const Version = 1
+
+type Cassette struct {
+	Version      int           `json:"version"`
+	Target       string        `json:"target,omitempty"` // where it was recorded
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"` // including the query string
+	// Header holds the request headers that can change the answer.
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status int    `json:"status"`
+	Body   string `json:"body,omitempty"`
+}
+
+func (r Request) String() string {
+	return r.Method + " " + r.Path
+}
+
+// Load reads a cassette file.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %v", path, err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("cassette %s: unsupported version %d", path, c.Version)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette as indented JSON, replacing path.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
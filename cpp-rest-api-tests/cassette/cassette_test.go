@@ -0,0 +1,173 @@
+package cassette
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/refapi"
+	"cpp-rest-api-tests/testenv"
+)
+
+var (
+	targetFlags = config.BindFlags(flag.CommandLine)
+	dir         = flag.String("cassette.dir", "../cassettes", "directory of cassettes to replay")
+)
+
+var api *client.Client
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { api = env.API })
+}
+
+// TestCassettes replays every recorded regression fixture.
+func TestCassettes(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(*dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			c, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := Replay(context.Background(), api, c, Options{Reset: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := report.Err(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// record sends requests through a Recorder in front of a server whose
+// next_id has been pushed to 8, so that replay has to map IDs.
+func record(t *testing.T, requests []Request) *Cassette {
+	t.Helper()
+	target, h := refapi.NewServer()
+	defer target.Close()
+	ctx := context.Background()
+	direct := client.New(target.URL, nil)
+	for i := 0; i < 7; i++ {
+		c, err := direct.Create(ctx, client.Contact{FirstName: "Drift"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		direct.Delete(ctx, c.ID)
+	}
+	if h.NextID() != 8 {
+		t.Fatalf("next_id = %d", h.NextID())
+	}
+
+	rec, err := NewRecorder(target.URL, filepath.Join(t.TempDir(), "c.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httptest.NewServer(rec)
+	defer proxy.Close()
+	via := client.New(proxy.URL, nil)
+	for _, r := range requests {
+		if _, err := via.Do(ctx, r.Method, r.Path, []byte(r.Body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saved, err := Load(rec.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Interactions) != len(requests) {
+		t.Fatalf("saved %d interactions, want %d", len(saved.Interactions), len(requests))
+	}
+	return saved
+}
+
+var session = []Request{
+	{Method: "POST", Path: "/records", Body: `{"first_name":"John","phone":"5551234567"}`},
+	{Method: "POST", Path: "/records", Body: `{"first_name":"Jane"}`},
+	{Method: "GET", Path: "/records/8"},
+	{Method: "PUT", Path: "/records/9", Body: `{"city":"Springfield"}`},
+	{Method: "GET", Path: "/records?id=9"},
+	{Method: "GET", Path: "/records/99"},
+	{Method: "DELETE", Path: "/records/8"},
+	{Method: "GET", Path: "/records"},
+	{Method: "DELETE", Path: "/reset"},
+	{Method: "POST", Path: "/records", Body: `{"first_name":"Again"}`},
+	{Method: "GET", Path: "/records/1"},
+}
+
+func TestReplayNormalizesIDs(t *testing.T) {
+	c := record(t, session)
+	if in := c.Interactions[0]; in.Request.Header["Content-Type"] != "application/json" || !strings.Contains(in.Response.Body, `"id":8`) {
+		t.Fatalf("first interaction: %+v", in)
+	}
+
+	srv, _ := refapi.NewServer()
+	defer srv.Close()
+	report, err := Replay(context.Background(), client.New(srv.URL, nil), c, Options{Reset: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if report.Replayed != len(session) {
+		t.Fatalf("replayed %d, want %d", report.Replayed, len(session))
+	}
+}
+
+func TestReplayReportsMismatches(t *testing.T) {
+	c := record(t, session)
+
+	// A server that forgets to apply updates.
+	ref := refapi.NewHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			r.Method = http.MethodGet
+		}
+		ref.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	report, err := Replay(context.Background(), client.New(srv.URL, nil), c, Options{Reset: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Replayed != len(session) {
+		t.Fatalf("replay stopped after %d interactions", report.Replayed)
+	}
+	if len(report.Mismatches) != 3 {
+		t.Fatalf("got %d mismatches, want 3 (PUT, query, list):\n%v", len(report.Mismatches), report.Err())
+	}
+	m := report.Mismatches[0]
+	if m.Index != 3 || m.Reason != "JSON bodies differ" || !strings.Contains(m.Error(), "#4 PUT /records/9") {
+		t.Fatalf("first mismatch:\n%s", m.Error())
+	}
+}
+
+func TestIDMapRewritesRequests(t *testing.T) {
+	m := newIDMap()
+	m.toReplay[8] = 1
+	tests := map[string]string{
+		"/records/8":              "/records/1",
+		"//records/8/":            "//records/1/",
+		"/records/9":              "/records/9",
+		"/records?id=8&phone=555": "/records?id=1&phone=555",
+		"/records?phone=555":      "/records?phone=555",
+		"/records/abc":            "/records/abc",
+		"/reset":                  "/reset",
+	}
+	for in, want := range tests {
+		if got := m.path(in); got != want {
+			t.Errorf("path(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+)
+
+// recordedHeaders are the request headers kept in a cassette.
+var recordedHeaders = []string{"Content-Type", "Accept"}
+
+// Recorder is a reverse proxy to Target that records every exchange. With
+// Path set, the cassette is saved after each one, so stopping the proxy
+// loses nothing.
+type Recorder struct {
+	Target string
+	Path   string
+
+	proxy    *httputil.ReverseProxy
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+func NewRecorder(target, path string) (*Recorder, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid target URL %q", target)
+	}
+	r := &Recorder{Target: target, Path: path, cassette: Cassette{Version: Version, Target: target}}
+	r.proxy = httputil.NewSingleHostReverseProxy(u)
+	r.proxy.ModifyResponse = r.record
+	return r, nil
+}
+
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Buffer the body so that record can read it again; the outgoing
+	// request is a clone and keeps GetBody.
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.proxy.ServeHTTP(w, req)
+}
+
+// record runs once the target has answered. An error makes the proxy
+// answer 502, so a cassette that cannot be saved is noticed.
+func (r *Recorder) record(resp *http.Response) error {
+	req := resp.Request
+	var reqBody []byte
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		reqBody, err = io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	in := Interaction{
+		Request: Request{
+			Method: req.Method,
+			Path:   req.URL.RequestURI(),
+			Body:   string(reqBody),
+		},
+		Response: Response{Status: resp.StatusCode, Body: string(respBody)},
+	}
+	for _, name := range recordedHeaders {
+		if v := req.Header.Get(name); v != "" {
+			if in.Request.Header == nil {
+				in.Request.Header = make(map[string]string)
+			}
+			in.Request.Header[name] = v
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	if r.Path == "" {
+		return nil
+	}
+	if err := r.cassette.Save(r.Path); err != nil {
+		return fmt.Errorf("failed to save cassette: %v", err)
+	}
+	return nil
+}
+
+// Cassette returns a copy of what has been recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.cassette
+	c.Interactions = append([]Interaction(nil), r.cassette.Interactions...)
+	return &c
+}
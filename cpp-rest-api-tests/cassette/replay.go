@@ -0,0 +1,231 @@
+package cassette
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/jsondiff"
+)
+
+// Mismatch is an interaction the server answered differently on replay.
+type Mismatch struct {
+	Index   int // into Cassette.Interactions
+	Request Request
+	Want    Response
+	Got     Response
+	Reason  string
+	Diff    string
+}
+
+func (m *Mismatch) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#%d %s: %s\n", m.Index+1, m.Request, m.Reason)
+	fmt.Fprintf(&b, "  recorded: %d %s\n", m.Want.Status, m.Want.Body)
+	fmt.Fprintf(&b, "  replayed: %d %s\n", m.Got.Status, m.Got.Body)
+	if m.Diff != "" {
+		fmt.Fprintf(&b, "response mismatch (-recorded +replayed):\n%s\n", m.Diff)
+	}
+	return b.String()
+}
+
+// Report is the outcome of a replay.
+type Report struct {
+	Replayed   int
+	Mismatches []*Mismatch
+}
+
+// Err returns nil when every interaction matched.
+func (r *Report) Err() error {
+	if len(r.Mismatches) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d interactions differ:\n", len(r.Mismatches), r.Replayed)
+	for _, m := range r.Mismatches {
+		b.WriteString(m.Error())
+	}
+	return fmt.Errorf("%s", strings.TrimSuffix(b.String(), "\n"))
+}
+
+type Options struct {
+	// Reset sends DELETE /reset before the first interaction, so the
+	// replay starts from an empty store as the recording should have.
+	Reset bool
+}
+
+// Replay sends every recorded request to api in order and compares the
+// answers. It carries on after a mismatch; the error is only for requests
+// that could not be sent.
+func Replay(ctx context.Context, api *client.Client, c *Cassette, opts Options) (*Report, error) {
+	if opts.Reset {
+		if err := api.Reset(ctx); err != nil {
+			return nil, err
+		}
+	}
+	ids := newIDMap()
+	report := &Report{}
+	for i, in := range c.Interactions {
+		req := in.Request
+		path := ids.path(req.Path)
+		var body []byte
+		if req.Body != "" {
+			body = []byte(req.Body)
+		}
+		resp, err := api.DoWithHeaders(ctx, req.Method, path, requestHeaders(req), body)
+		if err != nil {
+			return report, fmt.Errorf("#%d %s: %v", i+1, req, err)
+		}
+		report.Replayed++
+		got := Response{Status: resp.StatusCode, Body: string(resp.Body)}
+		if m := compare(in, got, ids); m != nil {
+			m.Index = i
+			report.Mismatches = append(report.Mismatches, m)
+			continue
+		}
+		ids.learn(in, got)
+	}
+	return report, nil
+}
+
+// requestHeaders sends exactly the recorded headers, leaving out the
+// client's default Content-Type when the recording had none.
+func requestHeaders(req Request) map[string]string {
+	headers := map[string]string{}
+	for _, name := range recordedHeaders {
+		headers[name] = req.Header[name]
+	}
+	return headers
+}
+
+func compare(in Interaction, got Response, ids *idMap) *Mismatch {
+	want := in.Response
+	m := &Mismatch{Request: in.Request, Want: want, Got: got}
+	if want.Status != got.Status {
+		m.Reason = fmt.Sprintf("status %d, recorded %d", got.Status, want.Status)
+		return m
+	}
+	var w, g interface{}
+	if json.Unmarshal([]byte(want.Body), &w) != nil || json.Unmarshal([]byte(got.Body), &g) != nil {
+		if want.Body != got.Body {
+			m.Reason = "bodies differ"
+			m.Diff = jsondiff.Diff(want.Body, got.Body)
+			return m
+		}
+		return nil
+	}
+	// A create's ID is new and becomes a mapping rather than a mismatch.
+	if in.Request.Method == http.MethodPost && got.Status == http.StatusCreated {
+		if obj, ok := g.(map[string]interface{}); ok {
+			if wobj, ok := w.(map[string]interface{}); ok {
+				obj["id"] = wobj["id"]
+			}
+		}
+	}
+	ca, _ := json.Marshal(w)
+	cg, _ := json.Marshal(ids.recorded(g))
+	if string(ca) != string(cg) {
+		m.Reason = "JSON bodies differ"
+		m.Diff = jsondiff.Diff(string(ca), string(cg))
+		return m
+	}
+	return nil
+}
+
+// idMap translates between the IDs seen while recording and on replay.
+type idMap struct {
+	toReplay   map[int]int
+	toRecorded map[int]int
+}
+
+func newIDMap() *idMap {
+	return &idMap{toReplay: map[int]int{}, toRecorded: map[int]int{}}
+}
+
+func (m *idMap) learn(in Interaction, got Response) {
+	switch {
+	case in.Request.Method == http.MethodDelete && isReset(in.Request.Path):
+		m.toReplay = map[int]int{}
+		m.toRecorded = map[int]int{}
+	case in.Request.Method == http.MethodPost && got.Status == http.StatusCreated:
+		recorded, ok1 := bodyID(in.Response.Body)
+		replayed, ok2 := bodyID(got.Body)
+		if ok1 && ok2 {
+			m.toReplay[recorded] = replayed
+			m.toRecorded[replayed] = recorded
+		}
+	}
+}
+
+var recordPath = regexp.MustCompile(`^(/+records/+)([^/?]+)(.*)$`)
+
+// path rewrites the recorded IDs in a request path and its id query
+// parameter. IDs the map does not know are left alone.
+func (m *idMap) path(p string) string {
+	p, query, hasQuery := strings.Cut(p, "?")
+	if sub := recordPath.FindStringSubmatch(p); sub != nil {
+		if id, err := strconv.Atoi(sub[2]); err == nil {
+			if replayed, ok := m.toReplay[id]; ok {
+				p = sub[1] + strconv.Itoa(replayed) + sub[3]
+			}
+		}
+	}
+	if !hasQuery {
+		return p
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return p + "?" + query
+	}
+	changed := false
+	for i, v := range values["id"] {
+		if id, err := strconv.Atoi(v); err == nil {
+			if replayed, ok := m.toReplay[id]; ok {
+				values["id"][i] = strconv.Itoa(replayed)
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return p + "?" + query
+	}
+	return p + "?" + values.Encode()
+}
+
+// recorded maps the "id" members of a decoded body back to recorded IDs.
+func (m *idMap) recorded(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if f, ok := v["id"].(float64); ok {
+			if id, ok := m.toRecorded[int(f)]; ok {
+				v["id"] = float64(id)
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			m.recorded(e)
+		}
+	}
+	return v
+}
+
+func isReset(path string) bool {
+	p, _, _ := strings.Cut(path, "?")
+	return strings.Trim(p, "/") == "reset"
+}
+
+func bodyID(body string) (int, bool) {
+	var r struct {
+		ID *int `json:"id"`
+	}
+	if json.Unmarshal([]byte(body), &r) != nil || r.ID == nil {
+		return 0, false
+	}
+	return *r.ID, true
+}
@@ -0,0 +1,283 @@
+// Package chaos is a reverse proxy that injects faults between a client
+// and the contacts API: extra latency, error statuses, dropped and reset
+// connections, and truncated bodies.
+//
+// Rules are checked in the order they were added. A latency rule delays
+// the request and lets later rules apply; any other fault ends it. Each
+// rule fires for Rate of the requests it matches, decided by a seeded
+// generator so that a run can be repeated.
+package chaos
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Fault string
+
+const (
+	Latency  Fault = "latency"  // wait Delay, then carry on
+	Status   Fault = "status"   // answer Status without asking the API
+	Drop     Fault = "drop"     // close the connection without answering
+	Reset    Fault = "reset"    // abort the connection with a TCP RST
+	Truncate Fault = "truncate" // send half the API's body, then close
+)
+
+// InjectedBody is the body of an injected status.
+const InjectedBody = "Injected fault"
+
+type Rule struct {
+	Method string // "" matches every method
+	// Path matches the request path exactly, or as a prefix when it ends
+	// in "*". "" matches every path.
+	Path   string
+	Fault  Fault
+	Rate   float64 // 0 < Rate <= 1
+	Status int
+	Delay  time.Duration
+}
+
+func (r Rule) String() string {
+	var parts []string
+	if r.Method != "" {
+		parts = append(parts, r.Method)
+	}
+	if r.Path != "" {
+		parts = append(parts, r.Path)
+	}
+	switch r.Fault {
+	case Latency:
+		parts = append(parts, "latency="+r.Delay.String())
+	case Status:
+		parts = append(parts, "status="+strconv.Itoa(r.Status))
+	default:
+		parts = append(parts, string(r.Fault))
+	}
+	if r.Rate > 0 && r.Rate < 1 {
+		parts = append(parts, strconv.FormatFloat(r.Rate*100, 'f', -1, 64)+"%")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (r Rule) matches(req *http.Request) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, req.Method) {
+		return false
+	}
+	switch {
+	case r.Path == "":
+		return true
+	case strings.HasSuffix(r.Path, "*"):
+		return strings.HasPrefix(req.URL.Path, strings.TrimSuffix(r.Path, "*"))
+	}
+	return req.URL.Path == r.Path
+}
+
+// ParseRule reads the rule syntax of cmd/chaos:
+//
+//	[METHOD] [PATH] FAULT [RATE%]
+//
+// where FAULT is latency=DURATION, status=CODE, drop, reset or truncate,
+// e.g. "GET /records latency=2s" or "status=500 30%".
+func ParseRule(s string) (Rule, error) {
+	r := Rule{Rate: 1}
+	for _, f := range strings.Fields(s) {
+		name, arg, hasArg := strings.Cut(f, "=")
+		switch {
+		case strings.HasSuffix(f, "%"):
+			pct, err := strconv.ParseFloat(strings.TrimSuffix(f, "%"), 64)
+			if err != nil || pct <= 0 || pct > 100 {
+				return Rule{}, fmt.Errorf("rule %q: invalid rate %q", s, f)
+			}
+			r.Rate = pct / 100
+		case strings.HasPrefix(f, "/"):
+			r.Path = f
+		case name == string(Latency) && hasArg:
+			d, err := time.ParseDuration(arg)
+			if err != nil || d <= 0 {
+				return Rule{}, fmt.Errorf("rule %q: invalid latency %q", s, arg)
+			}
+			r.Fault, r.Delay = Latency, d
+		case name == string(Status) && hasArg:
+			code, err := strconv.Atoi(arg)
+			if err != nil || code < 100 || code > 599 {
+				return Rule{}, fmt.Errorf("rule %q: invalid status %q", s, arg)
+			}
+			r.Fault, r.Status = Status, code
+		case !hasArg && (f == string(Drop) || f == string(Reset) || f == string(Truncate)):
+			r.Fault = Fault(f)
+		case !hasArg && f == strings.ToUpper(f):
+			r.Method = f
+		default:
+			return Rule{}, fmt.Errorf("rule %q: unexpected %q", s, f)
+		}
+	}
+	if r.Fault == "" {
+		return Rule{}, fmt.Errorf("rule %q: no fault given", s)
+	}
+	return r, nil
+}
+
+// Stats counts what the proxy has seen.
+type Stats struct {
+	Requests int
+	Injected map[Fault]int
+}
+
+// Proxy forwards to Target, injecting faults by its rules.
+type Proxy struct {
+	Target string
+
+	forward *httputil.ReverseProxy
+	mu      sync.Mutex
+	rules   []Rule
+	rng     *rand.Rand
+	stats   Stats
+}
+
+func NewProxy(target string, seed int64) (*Proxy, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid target URL %q", target)
+	}
+	return &Proxy{
+		Target:  target,
+		forward: httputil.NewSingleHostReverseProxy(u),
+		rng:     rand.New(rand.NewSource(seed)),
+		stats:   Stats{Injected: map[Fault]int{}},
+	}, nil
+}
+
+// Add appends a rule. A zero Rate means every request.
+func (p *Proxy) Add(r Rule) {
+	if r.Rate == 0 {
+		r.Rate = 1
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = append(p.rules, r)
+}
+
+// Clear removes every rule, so the proxy forwards untouched.
+func (p *Proxy) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = nil
+}
+
+func (p *Proxy) Rules() []Rule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Rule(nil), p.rules...)
+}
+
+func (p *Proxy) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := Stats{Requests: p.stats.Requests, Injected: map[Fault]int{}}
+	for f, n := range p.stats.Injected {
+		s.Injected[f] = n
+	}
+	return s
+}
+
+// pick decides which faults apply to req: the delays of every latency rule
+// that fires, then at most one other rule.
+func (p *Proxy) pick(req *http.Request) (time.Duration, *Rule) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Requests++
+	var delay time.Duration
+	for i := range p.rules {
+		r := p.rules[i]
+		if !r.matches(req) || p.rng.Float64() >= r.Rate {
+			continue
+		}
+		p.stats.Injected[r.Fault]++
+		if r.Fault == Latency {
+			delay += r.Delay
+			continue
+		}
+		return delay, &r
+	}
+	return delay, nil
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	delay, rule := p.pick(req)
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return
+		}
+	}
+	if rule == nil {
+		p.forward.ServeHTTP(w, req)
+		return
+	}
+	switch rule.Fault {
+	case Status:
+		w.Header().Set("Content-Length", strconv.Itoa(len(InjectedBody)))
+		w.WriteHeader(rule.Status)
+		fmt.Fprint(w, InjectedBody)
+	case Drop:
+		if conn := hijack(w); conn != nil {
+			conn.Close()
+		}
+	case Reset:
+		if conn := hijack(w); conn != nil {
+			if tcp, ok := conn.(*net.TCPConn); ok {
+				tcp.SetLinger(0)
+			}
+			conn.Close()
+		}
+	case Truncate:
+		p.truncate(w, req)
+	}
+}
+
+// truncate asks the API, then sends its status, its headers and the first
+// half of its body before closing the connection. The Content-Length still
+// promises the whole body, so an empty one goes out intact.
+func (p *Proxy) truncate(w http.ResponseWriter, req *http.Request) {
+	rec := httptest.NewRecorder()
+	p.forward.ServeHTTP(rec, req)
+	resp := rec.Result()
+	body := rec.Body.Bytes()
+	conn := hijack(w)
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	bw := bufio.NewWriter(conn)
+	fmt.Fprintf(bw, "HTTP/1.1 %d %s\r\n", resp.StatusCode, http.StatusText(resp.StatusCode))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	resp.Header.Set("Connection", "close")
+	resp.Header.Write(bw)
+	bw.WriteString("\r\n")
+	bw.Write(body[:len(body)/2])
+	bw.Flush()
+}
+
+func hijack(w http.ResponseWriter) net.Conn {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "chaos: connection cannot be hijacked", http.StatusInternalServerError)
+		return nil
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return nil
+	}
+	return conn
+}
@@ -0,0 +1,163 @@
+package chaos
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/testenv"
+)
+
+var (
+	targetFlags = config.BindFlags(flag.CommandLine)
+	slow        = flag.Bool("chaos.slow", false, "also wait out the configured client timeout")
+)
+
+var cfg config.Config
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { cfg = env.Config })
+}
+
+func start(t *testing.T, rules ...Rule) (*Proxy, string) {
+	t.Helper()
+	p, err := NewProxy(cfg.BaseURL, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rules {
+		p.Add(r)
+	}
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+	return p, srv.URL
+}
+
+func noKeepAlive(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &http.Transport{DisableKeepAlives: true}}
+}
+
+func TestFaults(t *testing.T) {
+	tests := []struct {
+		rule   Rule
+		method string
+		kind   string
+		status int
+	}{
+		{Rule{Fault: Status, Status: 503}, "GET", Answered, 503},
+		{Rule{Fault: Drop}, "GET", Dropped, 0},
+		{Rule{Fault: Reset}, "GET", WasReset, 0},
+		{Rule{Fault: Truncate}, "GET", Truncated, 200},
+		{Rule{Method: "POST", Fault: Status, Status: 500}, "GET", Answered, 200},
+		{Rule{Path: "/reset", Fault: Drop}, "GET", Answered, 200},
+		{Rule{Path: "/rec*", Fault: Drop}, "GET", Dropped, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule.String(), func(t *testing.T) {
+			_, url := start(t, tt.rule)
+			o := Send(context.Background(), noKeepAlive(5*time.Second), tt.method, url+"/records", nil)
+			if o.Kind != tt.kind || o.Status != tt.status {
+				t.Fatalf("got %s, want %s %d", o, tt.kind, tt.status)
+			}
+		})
+	}
+}
+
+func TestRateIsApplied(t *testing.T) {
+	p, url := start(t, Rule{Fault: Status, Status: 500, Rate: 0.3})
+	c := noKeepAlive(5 * time.Second)
+	failed := 0
+	for i := 0; i < 200; i++ {
+		if o := Send(context.Background(), c, "GET", url+"/records", nil); o.Status == 500 {
+			failed++
+		}
+	}
+	if failed < 40 || failed > 80 {
+		t.Fatalf("%d of 200 requests failed, want about 60", failed)
+	}
+	if s := p.Stats(); s.Requests != 200 || s.Injected[Status] != failed {
+		t.Fatalf("stats %+v, %d failed", s, failed)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	_, url := start(t, Rule{Method: "GET", Path: "/records", Fault: Latency, Delay: 300 * time.Millisecond})
+	o := Send(context.Background(), noKeepAlive(5*time.Second), "GET", url+"/records", nil)
+	if o.Kind != Answered || o.Duration < 300*time.Millisecond {
+		t.Fatalf("got %s, want a 200 after at least 300ms", o)
+	}
+	o = Send(context.Background(), noKeepAlive(100*time.Millisecond), "GET", url+"/records", nil)
+	if o.Kind != TimedOut {
+		t.Fatalf("got %s, want a timeout", o)
+	}
+}
+
+// The step library's client must give up once the configured timeout has
+// passed. That takes cfg.Timeout, ten seconds by default, so it only runs
+// with -chaos.slow.
+func TestConfiguredTimeout(t *testing.T) {
+	if !*slow {
+		t.Skip("run with -chaos.slow")
+	}
+	_, url := start(t, Rule{Fault: Latency, Delay: cfg.Timeout + time.Second})
+	o := SendClient(context.Background(), client.New(url, cfg.HTTPClient()), "GET", "/records", nil)
+	if o.Kind != TimedOut || o.Duration > cfg.Timeout+500*time.Millisecond {
+		t.Fatalf("got %s, want a timeout after %s", o, cfg.Timeout)
+	}
+}
+
+// Errors from the client package still say what went wrong underneath.
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		rule Rule
+		kind string
+	}{
+		{Rule{Fault: Latency, Delay: 300 * time.Millisecond}, TimedOut},
+		{Rule{Fault: Reset}, WasReset},
+		{Rule{Fault: Status, Status: 503}, Answered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			_, url := start(t, tt.rule)
+			httpClient := cfg.HTTPClient()
+			httpClient.Timeout = 100 * time.Millisecond
+			o := SendClient(context.Background(), client.New(url, httpClient), "GET", "/records", nil)
+			if o.Kind != tt.kind {
+				t.Fatalf("got %s, want %s", o, tt.kind)
+			}
+			if o.Err != nil && !strings.Contains(o.Err.Error(), "failed to send GET request") {
+				t.Fatalf("error %q does not come from the client package", o.Err)
+			}
+		})
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := map[string]Rule{
+		"GET /records latency=2s": {Method: "GET", Path: "/records", Fault: Latency, Delay: 2 * time.Second, Rate: 1},
+		"status=500 30%":          {Fault: Status, Status: 500, Rate: 0.3},
+		"/records/* drop 12.5%":   {Path: "/records/*", Fault: Drop, Rate: 0.125},
+		"POST reset":              {Method: "POST", Fault: Reset, Rate: 1},
+		"truncate":                {Fault: Truncate, Rate: 1},
+	}
+	for in, want := range tests {
+		got, err := ParseRule(in)
+		if err != nil || got != want {
+			t.Errorf("ParseRule(%q) = %+v, %v, want %+v", in, got, err, want)
+		}
+		if got.String() != in {
+			t.Errorf("%q.String() = %q", in, got.String())
+		}
+	}
+	for _, bad := range []string{"", "GET /records", "status=abc", "latency=-1s", "drop 0%", "drop 150%", "explode"} {
+		if _, err := ParseRule(bad); err == nil {
+			t.Errorf("ParseRule(%q) succeeded", bad)
+		}
+	}
+}
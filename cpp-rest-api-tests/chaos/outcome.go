@@ -0,0 +1,103 @@
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"cpp-rest-api-tests/client"
+)
+
+// Outcome kinds, as a client sees them.
+const (
+	Answered  = "answered"  // a complete response, whatever its status
+	TimedOut  = "timeout"   // the client gave up
+	Dropped   = "dropped"   // the connection closed before a response
+	WasReset  = "reset"     // the connection was reset
+	Truncated = "truncated" // the body ended early
+	Failed    = "error"     // anything else
+)
+
+// Outcome is how one request through the proxy ended.
+type Outcome struct {
+	Kind     string
+	Status   int
+	Body     []byte
+	Err      error
+	Duration time.Duration
+}
+
+func (o Outcome) String() string {
+	if o.Kind == Answered {
+		return fmt.Sprintf("%d after %s", o.Status, o.Duration.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("%s after %s: %v", o.Kind, o.Duration.Round(time.Millisecond), o.Err)
+}
+
+// Send makes one request with c and classifies how it ended.
+func Send(ctx context.Context, c *http.Client, method, url string, body []byte) Outcome {
+	start := time.Now()
+	o := send(ctx, c, method, url, body)
+	o.Duration = time.Since(start)
+	return o
+}
+
+// SendClient makes one request with the client package, as code using the
+// API would, and classifies the error it returns.
+func SendClient(ctx context.Context, c *client.Client, method, path string, body []byte) Outcome {
+	start := time.Now()
+	o := Outcome{Kind: Answered}
+	resp, err := c.Do(ctx, method, path, body)
+	if err != nil {
+		o = Outcome{Kind: classify(err), Err: err}
+	} else {
+		o.Status, o.Body = resp.StatusCode, resp.Body
+	}
+	o.Duration = time.Since(start)
+	return o
+}
+
+func send(ctx context.Context, c *http.Client, method, url string, body []byte) Outcome {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return Outcome{Kind: Failed, Err: err}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return Outcome{Kind: classify(err), Err: err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Outcome{Kind: classify(err), Status: resp.StatusCode, Body: data, Err: err}
+	}
+	return Outcome{Kind: Answered, Status: resp.StatusCode, Body: data}
+}
+
+func classify(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return TimedOut
+	case errors.Is(err, syscall.ECONNRESET):
+		return WasReset
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return Truncated
+	case errors.Is(err, io.EOF):
+		return Dropped
+	}
+	return Failed
+}
@@ -0,0 +1,298 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/scenario"
+)
+
+// sampleBody is sent with POST and PUT requests made through the proxy.
+const sampleBody = `{"first_name":"Chaos","phone":"5550001111"}`
+
+// Steps runs a chaos proxy in front of the API for one scenario. It starts
+// with the first fault step and is closed when the scenario ends.
+//
+// Requests through the proxy go through the client package with
+// httpClient, the client the step library was configured with, so they
+// meet its timeout and its error handling. httpClient should not check the
+// API contract: the injected faults are not part of it.
+type Steps struct {
+	api        *client.Client
+	httpClient *http.Client
+	proxy      *Proxy
+	server     *httptest.Server
+	timeout    time.Duration
+	outcomes   []Outcome
+}
+
+func NewSteps(api *client.Client, httpClient *http.Client) *Steps {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	s := &Steps{api: api, httpClient: httpClient, timeout: httpClient.Timeout}
+	if s.timeout <= 0 {
+		s.timeout = 10 * time.Second
+	}
+	return s
+}
+
+// on restricts a fault to one method and path.
+const on = `(?: on (GET|POST|PUT|DELETE) (\S+))?$`
+
+// rate limits a fault to a share of the requests.
+const rate = `(?: for (\d+)% of requests)?`
+
+func (s *Steps) Register(ctx scenario.Context) {
+	ctx.Step(`^the API responds with (\d+)`+rate+on, s.theAPIRespondsWith)
+	ctx.Step(`^latency of (\S+) is injected`+rate+on, s.latencyIsInjected)
+	ctx.Step(`^latency longer than the client timeout is injected`+rate+on, s.latencyLongerThanTheTimeoutIsInjected)
+	ctx.Step(`^connections are (dropped|reset)`+rate+on, s.connectionsAre)
+	ctx.Step(`^response bodies are truncated`+rate+on, s.responseBodiesAreTruncated)
+	ctx.Step(`^no faults are injected$`, s.noFaultsAreInjected)
+	ctx.Step(`^the client timeout is (\S+)$`, s.theClientTimeoutIs)
+	ctx.Step(`^I send (?:a|an|(\d+)) (GET|POST|PUT|DELETE) requests? to "([^"]*)" through the chaos proxy$`, s.iSendRequestsThroughTheChaosProxy)
+	ctx.Step(`^I send (?:a|an|(\d+)) raw (GET|POST|PUT|DELETE) requests? to "([^"]*)" through the chaos proxy$`, s.iSendRawRequestsThroughTheChaosProxy)
+	ctx.Step(`^the request should (time out|be dropped|be reset|be truncated)$`, s.theRequestShould)
+	ctx.Step(`^the client error should contain "([^"]*)"$`, s.theClientErrorShouldContain)
+	ctx.Step(`^the request should get status (\d+)$`, s.theRequestShouldGetStatus)
+	ctx.Step(`^the request should take at least (\S+)$`, s.theRequestShouldTakeAtLeast)
+	ctx.Step(`^between (\d+) and (\d+) of the requests should get status (\d+)$`, s.betweenOfTheRequestsShouldGetStatus)
+	ctx.Step(`^every request should get status (\d+)$`, s.everyRequestShouldGetStatus)
+	ctx.Step(`^the chaos proxy should have seen (\d+) requests?$`, s.theChaosProxyShouldHaveSeen)
+
+	ctx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		if s.server != nil {
+			s.server.Close()
+		}
+		return ctx, nil
+	})
+}
+
+// ensure starts the proxy the first time a step needs it.
+func (s *Steps) ensure() error {
+	if s.proxy != nil {
+		return nil
+	}
+	p, err := NewProxy(s.api.BaseURL, 1)
+	if err != nil {
+		return err
+	}
+	s.proxy = p
+	s.server = httptest.NewServer(p)
+	return nil
+}
+
+func (s *Steps) add(r Rule, pct, method, path string) error {
+	if err := s.ensure(); err != nil {
+		return err
+	}
+	if pct != "" {
+		n, err := strconv.Atoi(pct)
+		if err != nil || n <= 0 || n > 100 {
+			return fmt.Errorf("invalid rate %s%%", pct)
+		}
+		r.Rate = float64(n) / 100
+	}
+	r.Method, r.Path = method, path
+	s.proxy.Add(r)
+	return nil
+}
+
+func (s *Steps) theAPIRespondsWith(status int, pct, method, path string) error {
+	return s.add(Rule{Fault: Status, Status: status}, pct, method, path)
+}
+
+func (s *Steps) latencyIsInjected(delay, pct, method, path string) error {
+	d, err := time.ParseDuration(delay)
+	if err != nil {
+		return err
+	}
+	return s.add(Rule{Fault: Latency, Delay: d}, pct, method, path)
+}
+
+func (s *Steps) latencyLongerThanTheTimeoutIsInjected(pct, method, path string) error {
+	return s.add(Rule{Fault: Latency, Delay: s.timeout + time.Second}, pct, method, path)
+}
+
+func (s *Steps) connectionsAre(how, pct, method, path string) error {
+	fault := Drop
+	if how == "reset" {
+		fault = Reset
+	}
+	return s.add(Rule{Fault: fault}, pct, method, path)
+}
+
+func (s *Steps) responseBodiesAreTruncated(pct, method, path string) error {
+	return s.add(Rule{Fault: Truncate}, pct, method, path)
+}
+
+func (s *Steps) noFaultsAreInjected() error {
+	if err := s.ensure(); err != nil {
+		return err
+	}
+	s.proxy.Clear()
+	return nil
+}
+
+// theClientTimeoutIs replaces the configured timeout for this scenario.
+func (s *Steps) theClientTimeoutIs(timeout string) error {
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return err
+	}
+	s.timeout = d
+	return nil
+}
+
+// iSendRequestsThroughTheChaosProxy sends the requests one after another
+// with the client package and the configured *http.Client, so outcomes are
+// what code using the API would see.
+func (s *Steps) iSendRequestsThroughTheChaosProxy(ctx context.Context, count, method, path string) error {
+	if err := s.ensure(); err != nil {
+		return err
+	}
+	c := *s.httpClient
+	c.Timeout = s.timeout
+	api := client.New(s.server.URL, &c)
+	return s.send(count, method, func(body []byte) Outcome {
+		return SendClient(ctx, api, method, path, body)
+	})
+}
+
+// iSendRawRequestsThroughTheChaosProxy sends the requests with a bare
+// client, to tell dropped, reset and truncated connections apart. Keep-
+// alives are off so that net/http never retries a request behind the
+// proxy's back.
+func (s *Steps) iSendRawRequestsThroughTheChaosProxy(ctx context.Context, count, method, path string) error {
+	if err := s.ensure(); err != nil {
+		return err
+	}
+	c := &http.Client{Timeout: s.timeout, Transport: &http.Transport{DisableKeepAlives: true}}
+	return s.send(count, method, func(body []byte) Outcome {
+		return Send(ctx, c, method, s.server.URL+path, body)
+	})
+}
+
+func (s *Steps) send(count, method string, send func(body []byte) Outcome) error {
+	n := 1
+	if count != "" {
+		n, _ = strconv.Atoi(count)
+	}
+	var body []byte
+	if method == http.MethodPost || method == http.MethodPut {
+		body = []byte(sampleBody)
+	}
+	s.outcomes = nil
+	for i := 0; i < n; i++ {
+		s.outcomes = append(s.outcomes, send(body))
+	}
+	return nil
+}
+
+func (s *Steps) last() (Outcome, error) {
+	if len(s.outcomes) == 0 {
+		return Outcome{}, fmt.Errorf("no request has been sent through the chaos proxy")
+	}
+	return s.outcomes[len(s.outcomes)-1], nil
+}
+
+func (s *Steps) theRequestShould(what string) error {
+	want := map[string]string{
+		"time out":     TimedOut,
+		"be dropped":   Dropped,
+		"be reset":     WasReset,
+		"be truncated": Truncated,
+	}[what]
+	o, err := s.last()
+	if err != nil {
+		return err
+	}
+	if o.Kind != want {
+		return fmt.Errorf("expected the request to %s, got %s", what, o)
+	}
+	return nil
+}
+
+func (s *Steps) theClientErrorShouldContain(text string) error {
+	o, err := s.last()
+	if err != nil {
+		return err
+	}
+	if o.Err == nil || !strings.Contains(o.Err.Error(), text) {
+		return fmt.Errorf("expected a client error containing %q, got %s", text, o)
+	}
+	return nil
+}
+
+func (s *Steps) theRequestShouldGetStatus(status int) error {
+	o, err := s.last()
+	if err != nil {
+		return err
+	}
+	if o.Kind != Answered || o.Status != status {
+		return fmt.Errorf("expected status %d, got %s", status, o)
+	}
+	return nil
+}
+
+func (s *Steps) theRequestShouldTakeAtLeast(min string) error {
+	d, err := time.ParseDuration(min)
+	if err != nil {
+		return err
+	}
+	o, err := s.last()
+	if err != nil {
+		return err
+	}
+	if o.Duration < d {
+		return fmt.Errorf("expected the request to take at least %s, got %s", d, o)
+	}
+	return nil
+}
+
+func (s *Steps) betweenOfTheRequestsShouldGetStatus(min, max, status int) error {
+	if len(s.outcomes) == 0 {
+		return fmt.Errorf("no request has been sent through the chaos proxy")
+	}
+	n := 0
+	for _, o := range s.outcomes {
+		if o.Kind == Answered && o.Status == status {
+			n++
+		}
+	}
+	if n < min || n > max {
+		return fmt.Errorf("expected %d to %d of %d requests to get status %d, got %d", min, max, len(s.outcomes), status, n)
+	}
+	return nil
+}
+
+func (s *Steps) everyRequestShouldGetStatus(status int) error {
+	if len(s.outcomes) == 0 {
+		return fmt.Errorf("no request has been sent through the chaos proxy")
+	}
+	for i, o := range s.outcomes {
+		if o.Kind != Answered || o.Status != status {
+			return fmt.Errorf("request %d: expected status %d, got %s", i+1, status, o)
+		}
+	}
+	return nil
+}
+
+func (s *Steps) theChaosProxyShouldHaveSeen(n int) error {
+	if s.proxy == nil {
+		return fmt.Errorf("the chaos proxy has not been used in this scenario")
+	}
+	if got := s.proxy.Stats().Requests; got != n {
+		return fmt.Errorf("expected the chaos proxy to see %d requests, got %d", n, got)
+	}
+	return nil
+}
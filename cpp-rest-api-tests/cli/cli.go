@@ -0,0 +1,390 @@
+// Package cli implements the contacts command: one subcommand per
+// endpoint main.cpp registers with the Pistache router, so that nobody has
+// to assemble JSON for curl by hand.
+//
+//	contacts create --first-name Ada --last-name Lovelace --phone 5550000001
+//	contacts get 1
+//	contacts list -o yaml
+//	contacts query --city Springfield --phone 555
+//	contacts update 1 --email ada@example.com
+//	contacts delete 1 2
+//	contacts reset
+//
+// The target is a profile from package config, "local" by default; --url
+// overrides its base URL. Profiles that launch their own API are refused,
+// since whatever the command changed would vanish when it exits.
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+)
+
+// Command is one subcommand and the operations of the OpenAPI document it
+// calls.
+type Command struct {
+	Name       string
+	Args       string
+	Summary    string
+	Operations []string
+	run        func(e *env, fs *flag.FlagSet) error
+	flags      func(fs *flag.FlagSet)
+}
+
+var Commands = []*Command{
+	{Name: "create", Args: "[field flags]", Summary: "create a contact (POST /records)", Operations: []string{"createRecord"}, run: runCreate, flags: fieldFlags},
+	{Name: "get", Args: "ID...", Summary: "show contacts by ID (GET /records/{id})", Operations: []string{"getRecord"}, run: runGet},
+	{Name: "list", Summary: "list every contact (GET /records)", Operations: []string{"queryRecords"}, run: runList},
+	{Name: "query", Args: "[--id N] [field flags]", Summary: "find contacts matching every given field (GET /records?...)", Operations: []string{"queryRecords"}, run: runQuery, flags: queryFlags},
+	{Name: "update", Args: "ID [field flags]", Summary: "change the given fields of a contact (PUT /records/{id})", Operations: []string{"updateRecord"}, run: runUpdate, flags: fieldFlags},
+	{Name: "delete", Args: "ID...", Summary: "delete contacts (DELETE /records/{id})", Operations: []string{"deleteRecord"}, run: runDelete},
+	{Name: "reset", Args: "[--yes]", Summary: "delete every contact and restart IDs at 1 (DELETE /reset)", Operations: []string{"resetRecords"}, run: runReset, flags: resetFlags},
+}
+
+// fields are the contact fields as flags, in Record::to_json order.
+var fields = []struct{ flag, json string }{
+	{"first-name", "first_name"},
+	{"middle-name", "middle_name"},
+	{"last-name", "last_name"},
+	{"street", "street"},
+	{"city", "city"},
+	{"state", "state"},
+	{"zip", "zip"},
+	{"phone", "phone"},
+	{"email", "email"},
+}
+
+func fieldFlags(fs *flag.FlagSet) {
+	for _, f := range fields {
+		fs.String(f.flag, "", f.json)
+	}
+}
+
+func queryFlags(fs *flag.FlagSet) {
+	fs.String("id", "", "id")
+	fieldFlags(fs)
+}
+
+func resetFlags(fs *flag.FlagSet) {
+	fs.Bool("yes", false, "do not ask for confirmation")
+	fs.Bool("y", false, "shorthand for --yes")
+}
+
+// usageError makes Run exit 2 instead of 1.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...interface{}) error {
+	return usageError{fmt.Sprintf(format, args...)}
+}
+
+// env is what a subcommand runs with.
+type env struct {
+	ctx    context.Context
+	api    *client.Client
+	cfg    config.Config
+	out    *printer
+	stdin  *bufio.Reader
+	stderr io.Writer
+}
+
+// Run executes the command line args (without the program name) and
+// returns the exit status: 0 on success, 1 when the API call failed or the
+// user declined, 2 for usage errors.
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	var cmd *Command
+	for _, c := range Commands {
+		if c.Name == args[0] {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(stderr, "contacts: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("contacts "+cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	target := fs.String("target", "", "config profile to talk to (default $"+config.EnvProfile+" or "+config.DefaultProfile+")")
+	configPath := fs.String("config", "", "JSON config file with extra profiles (default $"+config.EnvConfig+")")
+	baseURL := fs.String("url", "", "base URL, overrides the profile")
+	timeout := fs.String("timeout", "", "HTTP timeout, e.g. 5s")
+	format := fs.String("o", "table", "output format: table, json or yaml")
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: %s [flags]\n\n%s\n\nflags:\n", strings.TrimSpace("contacts "+cmd.Name+" "+cmd.Args), cmd.Summary)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(interleave(fs, args[1:])); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	p, err := newPrinter(*format, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "contacts %s: %v\n", cmd.Name, err)
+		return 2
+	}
+	cfg, err := resolve(*target, *configPath, *baseURL, *timeout)
+	if err != nil {
+		fmt.Fprintf(stderr, "contacts %s: %v\n", cmd.Name, err)
+		return 2
+	}
+	e := &env{
+		ctx:    context.Background(),
+		api:    client.New(cfg.BaseURL, cfg.HTTPClient()),
+		cfg:    cfg,
+		out:    p,
+		stdin:  bufio.NewReader(stdin),
+		stderr: stderr,
+	}
+	if err := cmd.run(e, fs); err != nil {
+		fmt.Fprintf(stderr, "contacts %s: %v\n", cmd.Name, err)
+		if errors.As(err, new(usageError)) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: contacts COMMAND [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range Commands {
+		fmt.Fprintf(w, "  %-7s %s\n", c.Name, c.Summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Every command takes --target PROFILE, --url URL, --config FILE, --timeout D and -o table|json|yaml.")
+	fmt.Fprintln(w, "Run contacts COMMAND -h for its flags.")
+}
+
+// interleave moves positional arguments behind the flags, so that
+// "update 3 --city X" parses like "update --city X 3".
+func interleave(fs *flag.FlagSet, args []string) []string {
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			positional = append(positional, a)
+			continue
+		}
+		flags = append(flags, a)
+		name := strings.TrimLeft(a, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		if f := fs.Lookup(name); f != nil && !isBool(f) && i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	return append(flags, positional...)
+}
+
+func isBool(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// resolve loads the target profile and refuses ones that start their own
+// API.
+func resolve(target, configPath, baseURL, timeout string) (config.Config, error) {
+	return config.LoadRunning(&config.Flags{Profile: target, Config: configPath, BaseURL: baseURL, Timeout: timeout})
+}
+
+// set lists the flags given on the command line that are contact fields,
+// as a partial record. Flags given as "" are kept, to clear a field.
+func set(fs *flag.FlagSet) client.Fields {
+	given := client.Fields{}
+	fs.Visit(func(f *flag.Flag) {
+		for _, field := range fields {
+			if field.flag == f.Name {
+				given[field.json] = f.Value.String()
+			}
+		}
+	})
+	return given
+}
+
+func ids(args []string, min, max int) ([]int, error) {
+	if len(args) < min || (max > 0 && len(args) > max) {
+		if max == 1 {
+			return nil, usagef("expected one contact ID, got %d arguments", len(args))
+		}
+		return nil, usagef("expected contact IDs")
+	}
+	out := make([]int, len(args))
+	for i, a := range args {
+		id, err := strconv.Atoi(a)
+		if err != nil || id < 1 {
+			return nil, usagef("%q is not a contact ID", a)
+		}
+		out[i] = id
+	}
+	return out, nil
+}
+
+// explain rewrites a 404 from a /records/{id} call.
+func explain(id int, err error) error {
+	if errors.Is(err, client.ErrNotFound) {
+		return fmt.Errorf("contact %d not found", id)
+	}
+	return err
+}
+
+func runCreate(e *env, fs *flag.FlagSet) error {
+	if fs.NArg() > 0 {
+		return usagef("create takes no arguments; set fields with flags such as --first-name")
+	}
+	created, err := e.api.Create(e.ctx, set(fs).Apply(client.Contact{}))
+	if err != nil {
+		return err
+	}
+	return e.out.contact(created)
+}
+
+func runGet(e *env, fs *flag.FlagSet) error {
+	list, err := ids(fs.Args(), 1, 0)
+	if err != nil {
+		return err
+	}
+	var found []client.Contact
+	for _, id := range list {
+		c, err := e.api.Get(e.ctx, id)
+		if err != nil {
+			return explain(id, err)
+		}
+		found = append(found, c)
+	}
+	if len(found) == 1 {
+		return e.out.contact(found[0])
+	}
+	return e.out.contacts(found)
+}
+
+func runList(e *env, fs *flag.FlagSet) error {
+	if fs.NArg() > 0 {
+		return usagef("list takes no arguments; use query to filter")
+	}
+	all, err := e.api.List(e.ctx)
+	if err != nil {
+		return err
+	}
+	return e.out.contacts(all)
+}
+
+func runQuery(e *env, fs *flag.FlagSet) error {
+	if fs.NArg() > 0 {
+		return usagef("query takes no arguments; filter with flags such as --city")
+	}
+	// Extra carries every filter, so that --middle-name "" matches
+	// contacts without one instead of being dropped.
+	params := client.QueryParams{Extra: map[string][]string{}}
+	for name, value := range set(fs) {
+		params.Extra[name] = []string{value}
+	}
+	if f := fs.Lookup("id"); f.Value.String() != "" {
+		params.Extra["id"] = []string{f.Value.String()}
+	}
+	if len(params.Extra) == 0 {
+		return usagef("query needs at least one filter; use list for every contact")
+	}
+	found, err := e.api.Query(e.ctx, params)
+	if err != nil {
+		return err
+	}
+	return e.out.contacts(found)
+}
+
+func runUpdate(e *env, fs *flag.FlagSet) error {
+	list, err := ids(fs.Args(), 1, 1)
+	if err != nil {
+		return err
+	}
+	changes := set(fs)
+	if len(changes) == 0 {
+		return usagef("nothing to update; set fields with flags such as --city")
+	}
+	updated, err := e.api.Update(e.ctx, list[0], changes)
+	if err != nil {
+		return explain(list[0], err)
+	}
+	return e.out.contact(updated)
+}
+
+func runDelete(e *env, fs *flag.FlagSet) error {
+	list, err := ids(fs.Args(), 1, 0)
+	if err != nil {
+		return err
+	}
+	var deleted []int
+	for _, id := range list {
+		if err := e.api.Delete(e.ctx, id); err != nil {
+			if len(deleted) > 0 {
+				e.out.result(fmt.Sprintf("Deleted %s.", plural(len(deleted), "contact")), "deleted", deleted)
+			}
+			return explain(id, err)
+		}
+		deleted = append(deleted, id)
+	}
+	return e.out.result(fmt.Sprintf("Deleted %s.", plural(len(deleted), "contact")), "deleted", deleted)
+}
+
+func runReset(e *env, fs *flag.FlagSet) error {
+	if fs.NArg() > 0 {
+		return usagef("reset takes no arguments")
+	}
+	all, err := e.api.List(e.ctx)
+	if err != nil {
+		return err
+	}
+	yes := fs.Lookup("yes").Value.String() == "true" || fs.Lookup("y").Value.String() == "true"
+	if !yes {
+		fmt.Fprintf(e.stderr, "This deletes all %s at %s (profile %s) and restarts IDs at 1.\nContinue? [y/N] ", plural(len(all), "contact"), e.cfg.BaseURL, e.cfg.Profile)
+		answer, _ := e.stdin.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+		default:
+			return errors.New("aborted, nothing was deleted")
+		}
+	}
+	if err := e.api.Reset(e.ctx); err != nil {
+		return err
+	}
+	return e.out.result(fmt.Sprintf("Reset: removed %s.", plural(len(all), "contact")), "removed", len(all))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
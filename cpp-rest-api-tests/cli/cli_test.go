@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/openapi"
+	"cpp-rest-api-tests/refapi"
+)
+
+type result struct {
+	status         int
+	stdout, stderr string
+}
+
+func run(t *testing.T, url, stdin string, args ...string) result {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if url != "" {
+		args = append(args, "--url", url)
+	}
+	status := Run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return result{status, stdout.String(), stderr.String()}
+}
+
+func TestEveryOperationHasACommand(t *testing.T) {
+	var want, got []string
+	for _, op := range openapi.MustLoad().Operations {
+		want = append(want, op.ID)
+	}
+	seen := map[string]bool{}
+	for _, c := range Commands {
+		for _, id := range c.Operations {
+			if !seen[id] {
+				seen[id] = true
+				got = append(got, id)
+			}
+		}
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands cover %v, the API has %v", got, want)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	srv, _ := refapi.NewServer()
+	defer srv.Close()
+
+	r := run(t, srv.URL, "", "create", "--first-name", "Ada", "--last-name", "Lovelace", "--phone", "5550000001", "--city", "Springfield", "-o", "json")
+	var ada client.Contact
+	if r.status != 0 || json.Unmarshal([]byte(r.stdout), &ada) != nil || ada.ID != 1 || ada.City != "Springfield" {
+		t.Fatalf("create: %+v", r)
+	}
+	run(t, srv.URL, "", "create", "--first-name", "Grace", "--phone", "4440000002", "--city", "Springfield")
+	run(t, srv.URL, "", "create", "--first-name", "Alan", "--phone", "5550000003", "--city", "Wilmslow")
+
+	r = run(t, srv.URL, "", "query", "--city", "Springfield", "--phone", "555")
+	if r.status != 0 || !strings.HasPrefix(r.stdout, "ID  FIRST_NAME") || strings.Count(r.stdout, "\n") != 2 || !strings.Contains(r.stdout, "Ada") {
+		t.Fatalf("query:\n%s%s", r.stdout, r.stderr)
+	}
+
+	// Flags may follow the ID, and an empty flag clears the field.
+	r = run(t, srv.URL, "", "update", "1", "--city", "", "--email", "ada@example.com", "-o", "yaml")
+	if r.status != 0 || !strings.Contains(r.stdout, "city: \"\"\n") || !strings.Contains(r.stdout, "email: ada@example.com\n") || !strings.Contains(r.stdout, "first_name: Ada\n") {
+		t.Fatalf("update:\n%s%s", r.stdout, r.stderr)
+	}
+	r = run(t, srv.URL, "", "query", "--city", "", "-o", "json")
+	if r.status != 0 || !strings.Contains(r.stdout, `"first_name": "Ada"`) || strings.Contains(r.stdout, "Grace") {
+		t.Fatalf("query for an empty city:\n%s%s", r.stdout, r.stderr)
+	}
+
+	r = run(t, srv.URL, "", "get", "2", "3", "-o", "yaml")
+	if r.status != 0 || !strings.HasPrefix(r.stdout, "- id: 2\n  first_name: Grace\n") || !strings.Contains(r.stdout, "- id: 3\n") {
+		t.Fatalf("get:\n%s%s", r.stdout, r.stderr)
+	}
+
+	r = run(t, srv.URL, "", "delete", "2", "9")
+	if r.status != 1 || r.stdout != "Deleted 1 contact.\n" || !strings.Contains(r.stderr, "contact 9 not found") {
+		t.Fatalf("delete: %+v", r)
+	}
+	r = run(t, srv.URL, "", "get", "2")
+	if r.status != 1 || !strings.Contains(r.stderr, "contact 2 not found") {
+		t.Fatalf("get deleted: %+v", r)
+	}
+	r = run(t, srv.URL, "", "list", "-o", "json")
+	var all []client.Contact
+	if r.status != 0 || json.Unmarshal([]byte(r.stdout), &all) != nil || len(all) != 2 {
+		t.Fatalf("list: %+v", r)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	srv, _ := refapi.NewServer()
+	defer srv.Close()
+	for _, tt := range []struct {
+		url  string
+		args []string
+		want string
+	}{
+		{srv.URL, []string{"frobnicate"}, `unknown command "frobnicate"`},
+		{srv.URL, []string{"get", "abc"}, `"abc" is not a contact ID`},
+		{srv.URL, []string{"update", "1"}, "nothing to update"},
+		{srv.URL, []string{"query"}, "at least one filter"},
+		{srv.URL, []string{"list", "-o", "xml"}, `unknown output format "xml"`},
+		{"", []string{"list", "--target", "reference"}, `profile "reference" launches its own API`},
+		{"", []string{"list", "--target", "nope"}, `unknown profile "nope"`},
+	} {
+		r := run(t, tt.url, "", tt.args...)
+		if r.status != 2 || !strings.Contains(r.stderr, tt.want) {
+			t.Errorf("%v: status %d, stderr %q, want %q", tt.args, r.status, r.stderr, tt.want)
+		}
+	}
+}
+
+func TestResetAsksFirst(t *testing.T) {
+	srv, _ := refapi.NewServer()
+	defer srv.Close()
+	run(t, srv.URL, "", "create", "--first-name", "Ada")
+
+	for _, answer := range []string{"", "n\n", "nope\n"} {
+		r := run(t, srv.URL, answer, "reset")
+		if r.status != 1 || !strings.Contains(r.stderr, "deletes all 1 contact at "+srv.URL) || !strings.Contains(r.stderr, "aborted") {
+			t.Fatalf("answer %q: %+v", answer, r)
+		}
+	}
+	if r := run(t, srv.URL, "", "list", "-o", "json"); !strings.Contains(r.stdout, "Ada") {
+		t.Fatalf("declined reset deleted contacts: %s", r.stdout)
+	}
+
+	r := run(t, srv.URL, "yes\n", "reset", "-o", "json")
+	if r.status != 0 || r.stdout != "{\n  \"removed\": 1\n}\n" {
+		t.Fatalf("confirmed reset: %+v", r)
+	}
+	run(t, srv.URL, "", "create", "--first-name", "Grace")
+	r = run(t, srv.URL, "", "reset", "--yes")
+	if r.status != 0 || r.stdout != "Reset: removed 1 contact.\n" || r.stderr != "" {
+		t.Fatalf("reset --yes: %+v", r)
+	}
+}
+
+// A profile from a config file selects the target, as in the test suites.
+func TestTargetProfile(t *testing.T) {
+	srv, _ := refapi.NewServer()
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "contacts.json")
+	os.WriteFile(path, []byte(`{"profiles": {"staging": {"base_url": "`+srv.URL+`"}}}`), 0o644)
+
+	r := run(t, "", "", "create", "--first-name", "Ada", "--target", "staging", "--config", path)
+	if r.status != 0 || !strings.Contains(r.stdout, "Ada") {
+		t.Fatalf("create on staging: %+v", r)
+	}
+	if r := run(t, srv.URL, "", "list", "-o", "json"); !strings.Contains(r.stdout, "Ada") {
+		t.Fatalf("staging is not the server: %s", r.stdout)
+	}
+}
+
+func TestYAMLQuoting(t *testing.T) {
+	for in, want := range map[string]string{
+		"Ada":             "Ada",
+		"":                `""`,
+		"01234":           `"01234"`,
+		"5550000001":      `"5550000001"`,
+		"yes":             `"yes"`,
+		"Null":            `"Null"`,
+		" padded":         `" padded"`,
+		"a: b":            `"a: b"`,
+		"#1":              `"#1"`,
+		"@home":           `"@home"`,
+		"line\nbreak":     `"line\nbreak"`,
+		"O'Brien, Jr.":    "O'Brien, Jr.",
+		"Saint-Étienne":   "Saint-Étienne",
+		"ada@example.com": "ada@example.com",
+	} {
+		if got := yamlString(in); got != want {
+			t.Errorf("yamlString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
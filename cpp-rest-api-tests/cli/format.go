@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"cpp-rest-api-tests/client"
+)
+
+const (
+	Table = "table"
+	JSON  = "json"
+	YAML  = "yaml"
+)
+
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+func newPrinter(format string, w io.Writer) (*printer, error) {
+	switch format {
+	case Table, JSON, YAML:
+		return &printer{format: format, w: w}, nil
+	}
+	return nil, usagef("unknown output format %q (want table, json or yaml)", format)
+}
+
+// columns are the fields of a contact in Record::to_json order.
+func columns(c client.Contact) [][2]string {
+	return [][2]string{
+		{"id", strconv.Itoa(c.ID)},
+		{"first_name", c.FirstName},
+		{"middle_name", c.MiddleName},
+		{"last_name", c.LastName},
+		{"street", c.Street},
+		{"city", c.City},
+		{"state", c.State},
+		{"zip", c.Zip},
+		{"phone", c.Phone},
+		{"email", c.Email},
+	}
+}
+
+// contact prints one contact: a one-row table, a JSON object or a YAML
+// mapping.
+func (p *printer) contact(c client.Contact) error {
+	switch p.format {
+	case JSON:
+		return p.json(c)
+	case YAML:
+		p.yamlContact(c, "")
+		return nil
+	}
+	return p.table([]client.Contact{c})
+}
+
+func (p *printer) contacts(cs []client.Contact) error {
+	if cs == nil {
+		cs = []client.Contact{}
+	}
+	switch p.format {
+	case JSON:
+		return p.json(cs)
+	case YAML:
+		if len(cs) == 0 {
+			fmt.Fprintln(p.w, "[]")
+		}
+		for _, c := range cs {
+			p.yamlContact(c, "- ")
+		}
+		return nil
+	}
+	if len(cs) == 0 {
+		fmt.Fprintln(p.w, "No contacts.")
+		return nil
+	}
+	return p.table(cs)
+}
+
+// result prints the outcome of a command that returns no contact: message
+// for tables, {key: value} otherwise. value is an int or []int.
+func (p *printer) result(message, key string, value interface{}) error {
+	switch p.format {
+	case JSON:
+		return p.json(map[string]interface{}{key: value})
+	case YAML:
+		fmt.Fprintf(p.w, "%s: %s\n", key, yamlScalar(value))
+		return nil
+	}
+	_, err := fmt.Fprintln(p.w, message)
+	return err
+}
+
+func (p *printer) json(v interface{}) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+func (p *printer) table(cs []client.Contact) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	var header []string
+	for _, col := range columns(client.Contact{}) {
+		header = append(header, strings.ToUpper(col[0]))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, c := range cs {
+		var cells []string
+		for _, col := range columns(c) {
+			cells = append(cells, tableCell(col[1]))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// tableCell keeps a value on one line and makes empty ones visible.
+func tableCell(s string) string {
+	if s == "" {
+		return "-"
+	}
+	if strings.ContainsAny(s, "\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// yamlContact writes c as a block mapping; prefix "- " makes it a
+// sequence item.
+func (p *printer) yamlContact(c client.Contact, prefix string) {
+	indent := strings.Repeat(" ", len(prefix))
+	for i, col := range columns(c) {
+		lead := indent
+		if i == 0 {
+			lead = prefix
+		}
+		value := yamlString(col[1])
+		if col[0] == "id" {
+			value = col[1]
+		}
+		fmt.Fprintf(p.w, "%s%s: %s\n", lead, col[0], value)
+	}
+}
+
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case []int:
+		parts := make([]string, len(v))
+		for i, n := range v {
+			parts[i] = strconv.Itoa(n)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return yamlString(fmt.Sprint(v))
+}
+
+// yamlString writes s plain when YAML would read it back as the same
+// string, and double-quoted otherwise. Go's escapes are a subset of
+// YAML's, so strconv.Quote produces a valid double-quoted scalar.
+func yamlString(s string) string {
+	if needsQuotes(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func needsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return true
+	}
+	// Numbers, including zips and phones, would come back as ints.
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f || r == '\u0085' || r == '\u2028' || r == '\u2029' || r == '\ufeff' {
+			return true
+		}
+	}
+	return false
+}
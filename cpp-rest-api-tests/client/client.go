@@ -0,0 +1,292 @@
+// Package client is a typed Go client for the contacts API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Contact mirrors struct Record in main.cpp.
+type Contact struct {
+	ID         int    `json:"id"`
+	FirstName  string `json:"first_name"`
+	MiddleName string `json:"middle_name"`
+	LastName   string `json:"last_name"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	Zip        string `json:"zip"`
+	Phone      string `json:"phone"`
+	Email      string `json:"email"`
+}
+
+// FieldNames lists the JSON names of a contact's string fields, in the
+// order struct Record in main.cpp declares them. The ID is not a field.
+var FieldNames = []string{"first_name", "middle_name", "last_name", "street", "city", "state", "zip", "phone", "email"}
+
+// Field returns the field with the given JSON name, or nil when name is not
+// in FieldNames.
+func (c *Contact) Field(name string) *string {
+	switch name {
+	case "first_name":
+		return &c.FirstName
+	case "middle_name":
+		return &c.MiddleName
+	case "last_name":
+		return &c.LastName
+	case "street":
+		return &c.Street
+	case "city":
+		return &c.City
+	case "state":
+		return &c.State
+	case "zip":
+		return &c.Zip
+	case "phone":
+		return &c.Phone
+	case "email":
+		return &c.Email
+	}
+	return nil
+}
+
+// Set assigns the field with the given JSON name and reports whether there
+// is one.
+func (c *Contact) Set(name, value string) bool {
+	f := c.Field(name)
+	if f == nil {
+		return false
+	}
+	*f = value
+	return true
+}
+
+// Fields returns every field of c, without the ID.
+func (c Contact) Fields() Fields {
+	f := make(Fields, len(FieldNames))
+	for _, name := range FieldNames {
+		f[name] = *c.Field(name)
+	}
+	return f
+}
+
+// Fields is a partial set of contact fields keyed by their JSON names, as
+// accepted by PUT /records/:id.
+type Fields map[string]string
+
+// Apply returns c with f's fields set. Names that are not fields, such as
+// "id", are ignored.
+func (f Fields) Apply(c Contact) Contact {
+	for name, value := range f {
+		c.Set(name, value)
+	}
+	return c
+}
+
+// QueryParams filters GET /records. Empty fields are left out of the query;
+// use Extra for parameters that must be sent with an empty value or that
+// the server does not know about.
+type QueryParams struct {
+	ID         int
+	FirstName  string
+	MiddleName string
+	LastName   string
+	Street     string
+	City       string
+	State      string
+	Zip        string
+	Phone      string
+	Email      string
+	Extra      url.Values
+}
+
+// Encode renders the parameters as a query string without the leading "?".
+func (q QueryParams) Encode() string {
+	v := url.Values{}
+	if q.ID != 0 {
+		v.Set("id", fmt.Sprint(q.ID))
+	}
+	for name, value := range map[string]string{
+		"first_name":  q.FirstName,
+		"middle_name": q.MiddleName,
+		"last_name":   q.LastName,
+		"street":      q.Street,
+		"city":        q.City,
+		"state":       q.State,
+		"zip":         q.Zip,
+		"phone":       q.Phone,
+		"email":       q.Email,
+	} {
+		if value != "" {
+			v.Set(name, value)
+		}
+	}
+	for name, values := range q.Extra {
+		v[name] = append(v[name], values...)
+	}
+	return v.Encode()
+}
+
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrBadRequest = errors.New("bad request")
+)
+
+// Error is returned for any unexpected status code. It matches ErrNotFound
+// or ErrBadRequest with errors.Is and carries the server's text body.
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	}
+	return false
+}
+
+// Response is a raw exchange, for callers that want to look at status
+// codes and bodies themselves.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// New returns a client for the API at baseURL. A nil httpClient means
+// http.DefaultClient.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTPClient: httpClient}
+}
+
+// Do sends body (which may be nil) to path and returns whatever came back.
+// Only transport failures are errors.
+func (c *Client) Do(ctx context.Context, method, path string, body []byte) (*Response, error) {
+	return c.DoWithHeaders(ctx, method, path, nil, body)
+}
+
+// DoWithHeaders is Do with extra request headers. A header set to "" is
+// left out, so a body can be sent without the default Content-Type.
+// Transport errors wrap the error from net/http, so callers can tell a
+// timeout from a reset connection.
+func (c *Client) DoWithHeaders(ctx context.Context, method, path string, headers map[string]string, body []byte) (*Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %v", method, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		if value == "" {
+			req.Header.Del(name)
+		} else {
+			req.Header.Set(name, value)
+		}
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: data}, nil
+}
+
+func (c *Client) Create(ctx context.Context, contact Contact) (Contact, error) {
+	var created Contact
+	err := c.call(ctx, http.MethodPost, "/records", contact.Fields(), http.StatusCreated, &created)
+	return created, err
+}
+
+func (c *Client) Get(ctx context.Context, id int) (Contact, error) {
+	var contact Contact
+	err := c.call(ctx, http.MethodGet, fmt.Sprintf("/records/%d", id), nil, http.StatusOK, &contact)
+	return contact, err
+}
+
+func (c *Client) List(ctx context.Context) ([]Contact, error) {
+	return c.Query(ctx, QueryParams{})
+}
+
+func (c *Client) Query(ctx context.Context, params QueryParams) ([]Contact, error) {
+	path := "/records"
+	if q := params.Encode(); q != "" {
+		path += "?" + q
+	}
+	contacts := []Contact{}
+	err := c.call(ctx, http.MethodGet, path, nil, http.StatusOK, &contacts)
+	return contacts, err
+}
+
+// Update merges fields into the contact; fields left out keep their value.
+func (c *Client) Update(ctx context.Context, id int, fields Fields) (Contact, error) {
+	var contact Contact
+	err := c.call(ctx, http.MethodPut, fmt.Sprintf("/records/%d", id), fields, http.StatusOK, &contact)
+	return contact, err
+}
+
+func (c *Client) Delete(ctx context.Context, id int) error {
+	return c.call(ctx, http.MethodDelete, fmt.Sprintf("/records/%d", id), nil, http.StatusNoContent, nil)
+}
+
+// Reset removes every contact and restarts IDs at 1.
+func (c *Client) Reset(ctx context.Context) error {
+	return c.call(ctx, http.MethodDelete, "/reset", nil, http.StatusNoContent, nil)
+}
+
+func (c *Client) call(ctx context.Context, method, path string, in interface{}, want int, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("failed to marshal %s body: %v", method, err)
+		}
+	}
+	resp, err := c.Do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != want {
+		return &Error{Method: method, Path: path, StatusCode: resp.StatusCode, Body: string(resp.Body)}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Body, out); err != nil {
+		return fmt.Errorf("%s %s: invalid JSON in response: %v, body: %s", method, path, err, resp.Body)
+	}
+	return nil
+}
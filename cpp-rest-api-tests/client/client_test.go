@@ -0,0 +1,130 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/refapi"
+)
+
+func TestClientAgainstReference(t *testing.T) {
+	srv, _ := refapi.NewServer()
+	defer srv.Close()
+	c := client.New(srv.URL, nil)
+	ctx := context.Background()
+
+	john, err := c.Create(ctx, client.Contact{FirstName: "John", Phone: "5551234567", City: "Springfield"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if john.ID != 1 {
+		t.Fatalf("first contact got ID %d", john.ID)
+	}
+	if _, err := c.Create(ctx, client.Contact{FirstName: "Jane", Phone: "4441234567"}); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := c.Update(ctx, john.ID, client.Fields{"last_name": "Doe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.LastName != "Doe" || updated.FirstName != "John" {
+		t.Fatalf("update did not merge: %+v", updated)
+	}
+
+	found, err := c.Query(ctx, client.QueryParams{Phone: "555", City: "Springfield"})
+	if err != nil || len(found) != 1 || found[0].ID != john.ID {
+		t.Fatalf("query returned %+v, %v", found, err)
+	}
+	blank, err := c.Query(ctx, client.QueryParams{Extra: url.Values{"city": {""}}})
+	if err != nil || len(blank) != 1 || blank[0].FirstName != "Jane" {
+		t.Fatalf("blank city query returned %+v, %v", blank, err)
+	}
+
+	if err := c.Delete(ctx, john.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Get(ctx, john.ID)
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Body != "Record not found" {
+		t.Fatalf("expected the server's body in the error, got %v", err)
+	}
+
+	_, err = c.Update(ctx, 2, client.Fields{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(ctx, "PUT", "/records/2", []byte("{")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	all, err := c.List(ctx)
+	if err != nil || len(all) != 0 {
+		t.Fatalf("list after reset returned %+v, %v", all, err)
+	}
+}
+
+func TestErrorMatchesBadRequest(t *testing.T) {
+	err := error(&client.Error{Method: "POST", Path: "/records", StatusCode: 400, Body: "Invalid JSON"})
+	if !errors.Is(err, client.ErrBadRequest) || errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("unexpected matching for %v", err)
+	}
+}
+
+func TestDoWithHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+	c := client.New(srv.URL, nil)
+	ctx := context.Background()
+
+	if _, err := c.DoWithHeaders(ctx, "POST", "/records", map[string]string{"Accept": "text/html"}, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("Content-Type") != "application/json" || got.Get("Accept") != "text/html" {
+		t.Fatalf("unexpected headers %v", got)
+	}
+	if _, err := c.DoWithHeaders(ctx, "POST", "/records", map[string]string{"Content-Type": ""}, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["Content-Type"]; ok {
+		t.Fatalf("Content-Type was sent: %v", got)
+	}
+}
+
+func TestFieldsByName(t *testing.T) {
+	var c client.Contact
+	for _, name := range client.FieldNames {
+		if !c.Set(name, name) {
+			t.Fatalf("%s is not settable", name)
+		}
+	}
+	if c.FirstName != "first_name" || c.Email != "email" {
+		t.Fatalf("fields landed in the wrong place: %+v", c)
+	}
+	if c.Set("id", "7") || c.Set("nickname", "x") || c.ID != 0 {
+		t.Fatalf("id or an unknown name was set: %+v", c)
+	}
+
+	fields := c.Fields()
+	if len(fields) != len(client.FieldNames) || fields["zip"] != "zip" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	got := client.Fields{"city": "Paris", "id": "7"}.Apply(client.Contact{ID: 1, City: "London", Zip: "SW1Y"})
+	if got != (client.Contact{ID: 1, City: "Paris", Zip: "SW1Y"}) {
+		t.Fatalf("Apply gave %+v", got)
+	}
+}
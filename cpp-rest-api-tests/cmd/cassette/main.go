@@ -0,0 +1,110 @@
+// Command cassette records API traffic through a reverse proxy and replays
+// it against a server as a regression test.
+//
+//	cassette record -listen :8081 -o bug.json
+//	cassette replay -contacts.profile docker bug.json other.json
+//
+// Point curl, load_contacts.sh or another service at the -listen address
+// while recording. Replay exits 1 when an answer differs from the recording.
+//
+// Both pick the API like the test suites do, with -contacts.profile and the
+// other -contacts.* flags or CONTACTS_* variables. -target (record) and
+// -url (replay) override the profile's base URL.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"cpp-rest-api-tests/cassette"
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "record":
+		record(os.Args[2:])
+	case "replay":
+		os.Exit(replay(os.Args[2:]))
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: cassette record -o FILE [-contacts.profile P] [-target URL] [-listen ADDR]")
+	fmt.Fprintln(os.Stderr, "       cassette replay [-contacts.profile P] [-url URL] [-reset=false] FILE...")
+	os.Exit(2)
+}
+
+func record(args []string) {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	target := config.BindFlags(fs)
+	fs.StringVar(&target.BaseURL, "target", "", "base URL of the API to proxy, overrides the profile")
+	listen := fs.String("listen", ":8081", "address to accept client traffic on")
+	out := fs.String("o", "", "cassette file to write")
+	fs.Parse(args)
+	if *out == "" {
+		fmt.Fprintln(os.Stderr, "cassette record: -o is required")
+		os.Exit(2)
+	}
+	cfg, err := config.LoadRunning(target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cassette record: %v\n", err)
+		os.Exit(2)
+	}
+
+	rec, err := cassette.NewRecorder(cfg.BaseURL, *out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cassette record: %v\n", err)
+		os.Exit(2)
+	}
+	fmt.Printf("Recording %s -> %s into %s\n", *listen, cfg.BaseURL, *out)
+	log.Fatal(http.ListenAndServe(*listen, rec))
+}
+
+func replay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	target := config.BindCommandFlags(fs)
+	reset := fs.Bool("reset", true, "DELETE /reset before each cassette")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "cassette replay: no cassette files given")
+		return 2
+	}
+	cfg, err := config.LoadRunning(target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cassette replay: %v\n", err)
+		return 2
+	}
+
+	api := client.New(cfg.BaseURL, cfg.HTTPClient())
+	status := 0
+	for _, path := range fs.Args() {
+		c, err := cassette.Load(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			return 2
+		}
+		report, err := cassette.Replay(context.Background(), api, c, cassette.Options{Reset: *reset})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			return 2
+		}
+		if err := report.Err(); err != nil {
+			fmt.Printf("%s: %v\n", path, err)
+			status = 1
+			continue
+		}
+		fmt.Printf("%s: %d interactions match\n", path, report.Replayed)
+	}
+	return status
+}
@@ -0,0 +1,62 @@
+// Command chaos is a fault-injecting reverse proxy for the contacts API.
+//
+//	chaos -target http://localhost:8080 -listen :8082 \
+//	    -fault 'status=500 30%' -fault 'GET /records latency=2s'
+//
+// Each -fault is a rule: [METHOD] [PATH] FAULT [RATE%], where FAULT is
+// latency=DURATION, status=CODE, drop, reset or truncate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"cpp-rest-api-tests/chaos"
+)
+
+type rules []chaos.Rule
+
+func (r *rules) String() string {
+	parts := make([]string, len(*r))
+	for i, rule := range *r {
+		parts[i] = rule.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r *rules) Set(s string) error {
+	rule, err := chaos.ParseRule(s)
+	if err != nil {
+		return err
+	}
+	*r = append(*r, rule)
+	return nil
+}
+
+func main() {
+	target := flag.String("target", "http://localhost:8080", "base URL of the API to proxy")
+	listen := flag.String("listen", ":8082", "address to accept client traffic on")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed deciding which requests get a fault")
+	var faults rules
+	flag.Var(&faults, "fault", "fault rule, may be repeated")
+	flag.Parse()
+
+	p, err := chaos.NewProxy(*target, *seed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chaos: %v\n", err)
+		os.Exit(2)
+	}
+	for _, r := range faults {
+		p.Add(r)
+	}
+	fmt.Printf("Proxying %s -> %s (seed %d)\n", *listen, *target, *seed)
+	for _, r := range faults {
+		fmt.Printf("  %s\n", r)
+	}
+	log.Fatal(http.ListenAndServe(*listen, p))
+}
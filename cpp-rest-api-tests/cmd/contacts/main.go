@@ -0,0 +1,16 @@
+// Command contacts manages the records of a contacts API from the shell.
+// See package cli for the subcommands, or run "contacts help".
+//
+//	contacts list --target ci
+//	contacts query --city Springfield --phone 555 -o yaml
+package main
+
+import (
+	"os"
+
+	"cpp-rest-api-tests/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,51 @@
+// Command difftest sends the same generated traffic to two contacts API
+// servers and reports the first request they answer differently.
+//
+//	difftest -a http://localhost:8080 -b http://localhost:9090 -seed 7 -n 500
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"cpp-rest-api-tests/difftest"
+)
+
+func main() {
+	a := flag.String("a", "http://localhost:8080", "base URL of the first API")
+	b := flag.String("b", "", "base URL of the second API")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed for the generated sequence")
+	n := flag.Int("n", 200, "number of requests to send")
+	reset := flag.Bool("reset", true, "DELETE /reset on both targets first")
+	timeout := flag.Duration("timeout", 10*time.Second, "per-request timeout")
+	flag.Parse()
+
+	if *b == "" {
+		fmt.Fprintln(os.Stderr, "difftest: -b is required")
+		os.Exit(2)
+	}
+
+	runner := &difftest.Runner{
+		A:      *a,
+		B:      *b,
+		Client: &http.Client{Timeout: *timeout},
+		Reset:  *reset,
+	}
+	err := runner.Run(difftest.NewGenerator(*seed), *n)
+
+	var d *difftest.Divergence
+	switch {
+	case errors.As(err, &d):
+		fmt.Printf("seed %d: divergence after %d matching requests\n", *seed, len(d.Trace))
+		fmt.Print(d.Error())
+		os.Exit(1)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "seed %d: %v\n", *seed, err)
+		os.Exit(2)
+	}
+	fmt.Printf("seed %d: %d requests, no divergence\n", *seed, *n)
+}
@@ -0,0 +1,85 @@
+// Command loadgen seeds the contacts API and measures a weighted mix of
+// reads, creates and queries.
+//
+//	loadgen -url http://localhost:8080 -records 5000 -concurrency 8 -duration 30s -mix read=70,create=10,query=20
+//
+// The API is picked like the test suites pick theirs, with
+// -contacts.profile and the other -contacts.* flags or CONTACTS_*
+// variables; -url and -timeout override the profile.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/loadgen"
+)
+
+func main() {
+	target := config.BindCommandFlags(flag.CommandLine)
+	records := flag.Int("records", 1000, "contacts to seed before measuring")
+	concurrency := flag.Int("concurrency", 4, "parallel workers")
+	rate := flag.Int("rps", 0, "target requests per second across all workers (0 = as fast as possible)")
+	duration := flag.Duration("duration", 10*time.Second, "how long to measure")
+	requests := flag.Int("requests", 0, "stop after this many requests instead (0 = use -duration)")
+	mixFlag := flag.String("mix", "read=70,create=10,query=20", "operation weights")
+	format := flag.String("format", "text", "report format: text or json")
+	seed := flag.Int64("seed", 1, "seed for the operation sequence")
+	flag.Parse()
+
+	if *rate < 0 || *rate > loadgen.MaxRate {
+		usage(fmt.Sprintf("-rps must be between 0 and %d", loadgen.MaxRate))
+	}
+	if *format != "text" && *format != "json" {
+		usage(fmt.Sprintf("unknown -format %q, expected text or json", *format))
+	}
+	mix, err := loadgen.ParseMix(*mixFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadgen:", err)
+		os.Exit(2)
+	}
+	if *requests > 0 {
+		*duration = 0
+	}
+
+	cfg, err := config.LoadRunning(target)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadgen:", err)
+		os.Exit(2)
+	}
+	api := client.New(cfg.BaseURL, cfg.HTTPClient())
+	report, err := loadgen.Run(context.Background(), api, loadgen.Options{
+		Records:     *records,
+		Mix:         mix,
+		Concurrency: *concurrency,
+		Rate:        *rate,
+		Duration:    *duration,
+		Requests:    *requests,
+		Seed:        *seed,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadgen:", err)
+		os.Exit(1)
+	}
+
+	if *format == "json" {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteTable(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadgen:", err)
+		os.Exit(1)
+	}
+}
+
+func usage(msg string) {
+	fmt.Fprintln(os.Stderr, "loadgen:", msg)
+	flag.Usage()
+	os.Exit(2)
+}
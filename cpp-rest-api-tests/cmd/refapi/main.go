@@ -0,0 +1,28 @@
+// Command refapi serves the Go reference implementation of the contacts API
+// with the same command line as the C++ binary: an optional port argument,
+// then $PORT, then 8080.
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"cpp-rest-api-tests/refapi"
+)
+
+func main() {
+	port := "8080"
+	if len(os.Args) > 1 {
+		port = os.Args[1]
+	} else if p := os.Getenv("PORT"); p != "" {
+		port = p
+	}
+
+	h := refapi.NewHandler()
+	h.Log = os.Stdout
+
+	fmt.Printf("Starting API server on http://localhost:%s\n", port)
+	log.Fatal(http.ListenAndServe(":"+port, h))
+}
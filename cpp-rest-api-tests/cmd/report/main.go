@@ -0,0 +1,31 @@
+// Command report turns a godog cucumber JSON report into JUnit XML, an
+// HTML page and an entry in a JSON history of runs.
+//
+//	report -in report.json -junit junit.xml -html report.html -history history.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"cpp-rest-api-tests/reporter"
+)
+
+func main() {
+	in := flag.String("in", "report.json", "cucumber JSON report to read")
+	junit := flag.String("junit", "junit.xml", "JUnit XML file to write, empty to skip")
+	html := flag.String("html", "report.html", "HTML report to write, empty to skip")
+	history := flag.String("history", "history.json", "history file to append the run to, empty to skip")
+	keep := flag.Int("keep", reporter.DefaultKeep, "runs to keep in the history")
+	flag.Parse()
+
+	run, err := reporter.Generate(*in, reporter.Files{JUnit: *junit, HTML: *html, History: *history, Keep: *keep}, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "report: %v\n", err)
+		os.Exit(2)
+	}
+	fmt.Printf("%d scenarios: %d passed, %d failed, %d skipped\n",
+		len(run.Scenarios), run.Count(reporter.Passed), run.Count(reporter.Failed), run.Count(reporter.Skipped))
+}
@@ -0,0 +1,182 @@
+// Command transfer exports the contacts in the API to CSV, JSON Lines or
+// vCard 4.0, and imports them back through POST /records.
+//
+//	transfer export -o contacts.csv
+//	transfer export -format vcard > contacts.vcf
+//	transfer import -dry-run contacts.csv
+//	transfer import -map 'Given Name=first_name' -map Notes=- people.csv
+//
+// The format follows the file extension (.csv, .ndjson or .jsonl, .vcf)
+// unless -format is given. Import exits 1 when any row is rejected.
+//
+// The API is picked like the test suites pick theirs, with
+// -contacts.profile and the other -contacts.* flags or CONTACTS_*
+// variables; -url and -timeout override the profile.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/transfer"
+)
+
+type mappings []string
+
+func (m *mappings) String() string     { return strings.Join(*m, ", ") }
+func (m *mappings) Set(s string) error { *m = append(*m, s); return nil }
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "export":
+		os.Exit(export(os.Args[2:]))
+	case "import":
+		os.Exit(importFile(os.Args[2:]))
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: transfer export [-contacts.profile P] [-url URL] [-format csv|ndjson|vcard] [-o FILE]")
+	fmt.Fprintln(os.Stderr, "       transfer import [-contacts.profile P] [-url URL] [-format F] [-map COLUMN=FIELD]... [-dry-run] [-quiet] [-summary FILE] FILE")
+	os.Exit(2)
+}
+
+// pickFormat prefers the -format flag, then the file extension.
+func pickFormat(name, path string) (transfer.Format, error) {
+	if name != "" {
+		return transfer.ParseFormat(name)
+	}
+	if path == "" || path == "-" {
+		return "", fmt.Errorf("-format is required when using stdin or stdout")
+	}
+	return transfer.FormatFor(path)
+}
+
+func export(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	target := config.BindCommandFlags(fs)
+	format := fs.String("format", "", "csv, ndjson or vcard (default: from the -o extension)")
+	out := fs.String("o", "", "file to write (default stdout)")
+	fs.Parse(args)
+
+	f, err := pickFormat(*format, *out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "transfer export: %v\n", err)
+		return 2
+	}
+	cfg, err := config.LoadRunning(target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "transfer export: %v\n", err)
+		return 2
+	}
+	api := client.New(cfg.BaseURL, cfg.HTTPClient())
+	contacts, err := api.List(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "transfer export: %v\n", err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" && *out != "-" {
+		file, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "transfer export: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := transfer.Export(w, f, contacts); err != nil {
+		fmt.Fprintf(os.Stderr, "transfer export: %v\n", err)
+		return 1
+	}
+	if w != os.Stdout {
+		fmt.Printf("Exported %d contacts to %s\n", len(contacts), *out)
+	}
+	return 0
+}
+
+func importFile(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	target := config.BindCommandFlags(fs)
+	format := fs.String("format", "", "csv, ndjson or vcard (default: from the file extension)")
+	var pairs mappings
+	fs.Var(&pairs, "map", "COLUMN=FIELD, or COLUMN=- to skip a column; may be repeated")
+	dryRun := fs.Bool("dry-run", false, "validate the rows without creating contacts")
+	quiet := fs.Bool("quiet", false, "suppress per-row progress lines")
+	summaryPath := fs.String("summary", "", "also write the summary as JSON to this file")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	path := fs.Arg(0)
+	cfg, err := config.LoadRunning(target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "transfer import: %v\n", err)
+		return 2
+	}
+
+	f, err := pickFormat(*format, path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "transfer import: %v\n", err)
+		return 2
+	}
+	m, err := transfer.ParseMapping(pairs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "transfer import: %v\n", err)
+		return 2
+	}
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "transfer import: %v\n", err)
+			return 2
+		}
+		defer file.Close()
+		r = file
+	}
+	rows, err := transfer.Read(r, f, m)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "transfer import: %s: %v\n", path, err)
+		return 2
+	}
+
+	opts := transfer.Options{DryRun: *dryRun}
+	if !*quiet {
+		opts.Progress = os.Stdout
+	}
+	api := client.New(cfg.BaseURL, cfg.HTTPClient())
+	summary, err := transfer.Import(context.Background(), api, rows, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "transfer import: %v\n", err)
+		return 1
+	}
+	summary.WriteText(os.Stdout)
+	if *summaryPath != "" {
+		file, err := os.Create(*summaryPath)
+		if err == nil {
+			err = summary.WriteJSON(file)
+			file.Close()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "transfer import: failed to write summary: %v\n", err)
+			return 1
+		}
+	}
+	if len(summary.Rejected) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,450 @@
+// Package config resolves which contacts API instance the test suites talk to.
+//
+// Settings are layered, later sources overriding earlier ones:
+//
+//  1. the built-in or file-defined profile (default "local")
+//  2. the JSON config file named by CONTACTS_CONFIG or -contacts.config
+//  3. CONTACTS_* environment variables
+//  4. -contacts.* command line flags
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultProfile = "local"
+
+// Environment variables consulted by Load.
+const (
+	EnvProfile  = "CONTACTS_PROFILE"
+	EnvConfig   = "CONTACTS_CONFIG"
+	EnvBaseURL  = "CONTACTS_BASE_URL"
+	EnvTimeout  = "CONTACTS_TIMEOUT"
+	EnvHeaders  = "CONTACTS_HEADERS"
+	EnvLaunch   = "CONTACTS_LAUNCH"
+	EnvBinary   = "CONTACTS_API_BINARY"
+	EnvImage    = "CONTACTS_API_IMAGE"
+	EnvRestart  = "CONTACTS_RESTART"
+	EnvLogFile  = "CONTACTS_API_LOG"
+	EnvBuild    = "CONTACTS_BUILD"
+	EnvIsolate  = "CONTACTS_ISOLATION"
+	EnvContract = "CONTACTS_CONTRACT"
+	EnvPatch    = "CONTACTS_MERGE_PATCH"
+)
+
+// Launch modes for a suite-managed API process.
+const (
+	LaunchNone   = ""
+	LaunchBinary = "binary"
+	LaunchDocker = "docker"
+	// LaunchReference serves the in-process Go reference implementation
+	// (package refapi) instead of the C++ binary.
+	LaunchReference = "reference"
+)
+
+// Restart policies for a suite-managed API process.
+const (
+	RestartSuite    = "suite"
+	RestartFeature  = "feature"
+	RestartScenario = "scenario"
+)
+
+// Isolation levels applied by the scenario hooks in step_definitions.
+const (
+	// IsolationReset resets the store before and after every scenario and
+	// fails a scenario that starts with records left over.
+	IsolationReset = "reset"
+	// IsolationBefore only resets before each scenario.
+	IsolationBefore = "before"
+	// IsolationNone installs no hooks.
+	IsolationNone = "none"
+)
+
+type Config struct {
+	Profile string
+	BaseURL string
+	Timeout time.Duration
+	Headers map[string]string
+
+	// Launch, when set, makes the suite start its own API instead of
+	// talking to BaseURL; see package apiserver.
+	Launch  string
+	Binary  string
+	Image   string
+	Restart string
+	LogFile string
+	Build   bool
+
+	Isolation string
+	// Contract checks every exchange made by the step library against the
+	// OpenAPI document in package openapi.
+	Contract bool
+	// MergePatch expects a PATCH /records/{id} route with RFC 7396 JSON
+	// Merge Patch semantics; see package updates. main.cpp has none.
+	MergePatch bool
+}
+
+// Profile is one named target as it appears in a config file.
+type Profile struct {
+	BaseURL string            `json:"base_url"`
+	Timeout string            `json:"timeout"`
+	Headers map[string]string `json:"headers"`
+	Launch  string            `json:"launch"`
+	Binary  string            `json:"binary"`
+	Image   string            `json:"image"`
+	Restart string            `json:"restart"`
+	LogFile string            `json:"log_file"`
+	Build   bool              `json:"build"`
+
+	Isolation  string `json:"isolation"`
+	Contract   *bool  `json:"contract"`
+	MergePatch bool   `json:"merge_patch"`
+}
+
+type fileFormat struct {
+	Profile  string             `json:"profile"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+var builtinProfiles = map[string]Profile{
+	"local":     {BaseURL: "http://localhost:8080", Timeout: "10s"},
+	"docker":    {BaseURL: "http://api-container:8080", Timeout: "10s"},
+	"ci":        {BaseURL: "http://localhost:8080", Timeout: "30s"},
+	"managed":   {Launch: LaunchBinary, Timeout: "10s", Build: true},
+	"reference": {Launch: LaunchReference, Timeout: "10s"},
+}
+
+// Flags holds the values of the -contacts.* command line flags.
+type Flags struct {
+	Profile string
+	Config  string
+	BaseURL string
+	Timeout string
+	Headers headerList
+	Launch  string
+	Binary  string
+	Image   string
+	Restart string
+	LogFile string
+	Build   bool
+
+	Isolation  string
+	Contract   string
+	MergePatch string
+}
+
+type headerList []string
+
+func (h *headerList) String() string { return strings.Join(*h, ",") }
+
+func (h *headerList) Set(v string) error {
+	*h = append(*h, v)
+	return nil
+}
+
+// BindFlags registers the -contacts.* flags on set. Call it before the flag
+// set is parsed, e.g. from TestMain before flag.Parse.
+func BindFlags(set *flag.FlagSet) *Flags {
+	f := &Flags{}
+	set.StringVar(&f.Profile, "contacts.profile", "", "named target profile ("+strings.Join(ProfileNames(nil), ", ")+")")
+	set.StringVar(&f.Config, "contacts.config", "", "path to a JSON config file with extra profiles")
+	set.StringVar(&f.BaseURL, "contacts.base-url", "", "base URL of the contacts API, overrides the profile")
+	set.StringVar(&f.Timeout, "contacts.timeout", "", "HTTP client timeout, e.g. 5s")
+	set.Var(&f.Headers, "contacts.header", "extra request header as Name=value (repeatable)")
+	set.StringVar(&f.Launch, "contacts.launch", "", "start the API from the suite: binary, docker or reference")
+	set.StringVar(&f.Binary, "contacts.binary", "", "path to the api binary when launching it (default: found next to main.cpp)")
+	set.StringVar(&f.Image, "contacts.image", "", "Docker image to run when -contacts.launch=docker")
+	set.StringVar(&f.Restart, "contacts.restart", "", "restart a launched API per suite, feature or scenario")
+	set.StringVar(&f.LogFile, "contacts.log", "", "file receiving a launched API's output")
+	set.BoolVar(&f.Build, "contacts.build", false, "compile main.cpp with g++ before launching the binary")
+	set.StringVar(&f.Isolation, "contacts.isolation", "", "scenario isolation: reset (default), before or none")
+	set.StringVar(&f.Contract, "contacts.contract", "", "check every step's request and response against the OpenAPI spec (default true)")
+	set.StringVar(&f.MergePatch, "contacts.merge-patch", "", "expect PATCH /records/{id} to implement JSON Merge Patch (default false)")
+	return f
+}
+
+// BindCommandFlags is BindFlags for commands that talk to a running API. It
+// also registers -url and -timeout as short forms of -contacts.base-url and
+// -contacts.timeout.
+func BindCommandFlags(set *flag.FlagSet) *Flags {
+	f := BindFlags(set)
+	set.StringVar(&f.BaseURL, "url", "", "base URL of the contacts API, overrides the profile")
+	set.StringVar(&f.Timeout, "timeout", "", "per-request timeout, e.g. 5s, overrides the profile")
+	return f
+}
+
+// Load resolves the configuration from profiles, the config file, the
+// environment and flags. flags may be nil.
+func Load(flags *Flags) (Config, error) {
+	if flags == nil {
+		flags = &Flags{}
+	}
+
+	profiles := make(map[string]Profile, len(builtinProfiles))
+	for name, p := range builtinProfiles {
+		profiles[name] = p
+	}
+
+	profileName := DefaultProfile
+	configPath := firstNonEmpty(flags.Config, os.Getenv(EnvConfig))
+	if configPath != "" {
+		file, err := readFile(configPath)
+		if err != nil {
+			return Config{}, err
+		}
+		for name, p := range file.Profiles {
+			profiles[name] = p
+		}
+		if file.Profile != "" {
+			profileName = file.Profile
+		}
+	}
+	profileName = firstNonEmpty(flags.Profile, os.Getenv(EnvProfile), profileName)
+
+	p, ok := profiles[profileName]
+	if !ok {
+		return Config{}, fmt.Errorf("config: unknown profile %q (known: %s)", profileName, strings.Join(ProfileNames(profiles), ", "))
+	}
+
+	cfg := Config{
+		Profile: profileName,
+		BaseURL: p.BaseURL,
+		Headers: map[string]string{},
+		Launch:  firstNonEmpty(flags.Launch, os.Getenv(EnvLaunch), p.Launch),
+		Binary:  firstNonEmpty(flags.Binary, os.Getenv(EnvBinary), p.Binary),
+		Image:   firstNonEmpty(flags.Image, os.Getenv(EnvImage), p.Image),
+		Restart: firstNonEmpty(flags.Restart, os.Getenv(EnvRestart), p.Restart, RestartSuite),
+		LogFile: firstNonEmpty(flags.LogFile, os.Getenv(EnvLogFile), p.LogFile),
+		Build:   p.Build,
+
+		Isolation: firstNonEmpty(flags.Isolation, os.Getenv(EnvIsolate), p.Isolation, IsolationReset),
+	}
+	// Naming a binary means "run this one", unless a build was asked for
+	// explicitly as well.
+	if firstNonEmpty(flags.Binary, os.Getenv(EnvBinary)) != "" {
+		cfg.Build = false
+	}
+	if v := os.Getenv(EnvBuild); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: invalid %s %q: %v", EnvBuild, v, err)
+		}
+		cfg.Build = b
+	}
+	cfg.Build = cfg.Build || flags.Build
+	cfg.Contract = p.Contract == nil || *p.Contract
+	if v := firstNonEmpty(flags.Contract, os.Getenv(EnvContract)); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: invalid contract setting %q: %v", v, err)
+		}
+		cfg.Contract = b
+	}
+	cfg.MergePatch = p.MergePatch
+	if v := firstNonEmpty(flags.MergePatch, os.Getenv(EnvPatch)); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: invalid merge patch setting %q: %v", v, err)
+		}
+		cfg.MergePatch = b
+	}
+	for k, v := range p.Headers {
+		cfg.Headers[http.CanonicalHeaderKey(k)] = v
+	}
+
+	timeout := p.Timeout
+	if v := firstNonEmpty(flags.Timeout, os.Getenv(EnvTimeout)); v != "" {
+		timeout = v
+	}
+	if timeout == "" {
+		timeout = "10s"
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return Config{}, fmt.Errorf("config: invalid timeout %q: %v", timeout, err)
+	}
+	cfg.Timeout = d
+
+	if explicit := firstNonEmpty(flags.BaseURL, os.Getenv(EnvBaseURL)); explicit != "" {
+		cfg.BaseURL = explicit
+		// Pointing at a URL means "use what is running there", unless a
+		// launch mode was asked for explicitly as well.
+		if firstNonEmpty(flags.Launch, os.Getenv(EnvLaunch)) == "" {
+			cfg.Launch = LaunchNone
+		}
+	}
+
+	var headers []string
+	if env := os.Getenv(EnvHeaders); env != "" {
+		headers = append(headers, strings.Split(env, ",")...)
+	}
+	headers = append(headers, flags.Headers...)
+	for _, h := range headers {
+		name, value, err := parseHeader(h)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Headers[name] = value
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// MustLoad is Load for test entry points: it exits with a readable message
+// instead of letting every scenario fail on a bad target.
+func MustLoad(flags *Flags) Config {
+	cfg, err := Load(flags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return cfg
+}
+
+// LoadRunning is Load for commands that talk to an API someone else
+// started. It refuses profiles that launch their own, which only the test
+// entry points can do.
+func LoadRunning(flags *Flags) (Config, error) {
+	cfg, err := Load(flags)
+	if err != nil {
+		return cfg, err
+	}
+	if cfg.Launch != LaunchNone {
+		return cfg, fmt.Errorf("config: profile %q launches its own API (%s); pick a profile with a base URL or pass -url", cfg.Profile, cfg.Launch)
+	}
+	return cfg, nil
+}
+
+func (c Config) Validate() error {
+	switch c.Launch {
+	case LaunchNone:
+	case LaunchBinary, LaunchReference:
+	case LaunchDocker:
+		if c.Image == "" {
+			return fmt.Errorf("config: launch mode docker needs an image; set %s or -contacts.image", EnvImage)
+		}
+	default:
+		return fmt.Errorf("config: unknown launch mode %q (known: binary, docker, reference)", c.Launch)
+	}
+	switch c.Restart {
+	case RestartSuite, RestartFeature, RestartScenario:
+	default:
+		return fmt.Errorf("config: unknown restart policy %q (known: suite, feature, scenario)", c.Restart)
+	}
+	switch c.Isolation {
+	case IsolationReset, IsolationBefore, IsolationNone:
+	default:
+		return fmt.Errorf("config: unknown isolation %q (known: reset, before, none)", c.Isolation)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("config: timeout must be positive, got %s", c.Timeout)
+	}
+	if c.Launch != LaunchNone && c.BaseURL == "" {
+		// The launcher fills in BaseURL once it has picked a port.
+		return nil
+	}
+	if c.BaseURL == "" {
+		return fmt.Errorf("config: profile %q has no base URL; set %s or -contacts.base-url", c.Profile, EnvBaseURL)
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return fmt.Errorf("config: invalid base URL %q: %v", c.BaseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("config: base URL %q must use http or https", c.BaseURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("config: base URL %q has no host", c.BaseURL)
+	}
+	return nil
+}
+
+// URL joins path onto the configured base URL.
+func (c Config) URL(path string) string {
+	return strings.TrimRight(c.BaseURL, "/") + path
+}
+
+// HTTPClient returns a client honouring the configured timeout and headers.
+func (c Config) HTTPClient() *http.Client {
+	return &http.Client{
+		Timeout:   c.Timeout,
+		Transport: &headerTransport{headers: c.Headers, base: http.DefaultTransport},
+	}
+}
+
+type headerTransport struct {
+	headers map[string]string
+	base    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.headers) == 0 {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		if req.Header.Get(k) == "" {
+			req.Header.Set(k, v)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// ProfileNames lists the profile names in profiles, or the built-in ones
+// when profiles is nil.
+func ProfileNames(profiles map[string]Profile) []string {
+	if profiles == nil {
+		profiles = builtinProfiles
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(path string) (fileFormat, error) {
+	var file fileFormat
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, fmt.Errorf("config: failed to read %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("config: failed to parse %s: %v", path, err)
+	}
+	return file, nil
+}
+
+func parseHeader(h string) (string, string, error) {
+	name, value, ok := strings.Cut(h, "=")
+	if !ok {
+		name, value, ok = strings.Cut(h, ":")
+	}
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("config: invalid header %q, expected Name=value", h)
+	}
+	return http.CanonicalHeaderKey(name), strings.TrimSpace(value), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func clearEnv(t *testing.T) {
+	for _, k := range []string{EnvProfile, EnvConfig, EnvBaseURL, EnvTimeout, EnvHeaders, EnvLaunch, EnvBinary, EnvImage, EnvRestart, EnvLogFile, EnvBuild, EnvIsolate, EnvContract, EnvPatch} {
+		t.Setenv(k, "")
+	}
+}
+
+func TestLoadDefaultsToLocalProfile(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "local" || cfg.BaseURL != "http://localhost:8080" || cfg.Timeout != 10*time.Second ||
+		cfg.Launch != LaunchNone || cfg.Isolation != IsolationReset || !cfg.Contract || cfg.MergePatch {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestLoadReferenceProfile(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load(&Flags{Profile: "reference"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "reference" || cfg.Launch != LaunchReference || cfg.BaseURL != "" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+
+	t.Setenv(EnvProfile, "reference")
+	if cfg, err = Load(nil); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Launch != LaunchReference {
+		t.Fatalf("%s=reference: unexpected config: %+v", EnvProfile, cfg)
+	}
+}
+
+func TestCommandFlags(t *testing.T) {
+	clearEnv(t)
+	fs := flag.NewFlagSet("cmd", flag.ContinueOnError)
+	flags := BindCommandFlags(fs)
+	if err := fs.Parse([]string{"-contacts.profile=docker", "-url", "http://127.0.0.1:9090", "-timeout", "2s"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadRunning(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "docker" || cfg.BaseURL != "http://127.0.0.1:9090" || cfg.Timeout != 2*time.Second {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+
+	for _, profile := range []string{"reference", "managed"} {
+		if _, err := LoadRunning(&Flags{Profile: profile}); err == nil || !strings.Contains(err.Error(), "launches its own API") {
+			t.Errorf("%s: got %v, want the profile refused", profile, err)
+		}
+	}
+}
+
+func TestManagedProfileBuilds(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		flags Flags
+		want  bool
+	}{
+		{"default", nil, Flags{}, true},
+		{"env off", map[string]string{EnvBuild: "0"}, Flags{}, false},
+		{"binary named", nil, Flags{Binary: "/opt/api"}, false},
+		{"binary in env", map[string]string{EnvBinary: "/opt/api"}, Flags{}, false},
+		{"binary and build flag", nil, Flags{Binary: "/opt/api", Build: true}, true},
+		{"binary and build env", map[string]string{EnvBinary: "/opt/api", EnvBuild: "1"}, Flags{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			tt.flags.Profile = "managed"
+			cfg, err := Load(&tt.flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Launch != LaunchBinary || cfg.Build != tt.want {
+				t.Fatalf("launch %q build %v, want binary build %v", cfg.Launch, cfg.Build, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "contacts.json")
+	file := `{"profile":"staging","profiles":{"staging":{"base_url":"https://staging.example.com","timeout":"3s","headers":{"x-team":"qa"}}}}`
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvConfig, path)
+	t.Setenv(EnvTimeout, "4s")
+	t.Setenv(EnvHeaders, "Authorization=Bearer abc")
+
+	cfg, err := Load(&Flags{BaseURL: "http://127.0.0.1:9999", Headers: headerList{"X-Team: ops"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "staging" {
+		t.Errorf("profile = %q, want staging", cfg.Profile)
+	}
+	if cfg.BaseURL != "http://127.0.0.1:9999" {
+		t.Errorf("base URL = %q, flag should win", cfg.BaseURL)
+	}
+	if cfg.Timeout != 4*time.Second {
+		t.Errorf("timeout = %s, env should win over file", cfg.Timeout)
+	}
+	if cfg.Headers["X-Team"] != "ops" || cfg.Headers["Authorization"] != "Bearer abc" {
+		t.Errorf("unexpected headers: %v", cfg.Headers)
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags Flags
+		want  string
+	}{
+		{"unknown profile", Flags{Profile: "prod"}, `unknown profile "prod"`},
+		{"bad scheme", Flags{BaseURL: "localhost:8080"}, "must use http or https"},
+		{"no host", Flags{BaseURL: "http://"}, "has no host"},
+		{"bad timeout", Flags{Timeout: "soon"}, `invalid timeout "soon"`},
+		{"zero timeout", Flags{Timeout: "0s"}, "timeout must be positive"},
+		{"bad header", Flags{Headers: headerList{"novalue"}}, `invalid header "novalue"`},
+		{"bad launch", Flags{Launch: "podman"}, `unknown launch mode "podman"`},
+		{"docker without image", Flags{Launch: "docker"}, "needs an image"},
+		{"bad restart", Flags{Restart: "always"}, `unknown restart policy "always"`},
+		{"bad isolation", Flags{Isolation: "sometimes"}, `unknown isolation "sometimes"`},
+		{"bad contract", Flags{Contract: "maybe"}, `invalid contract setting "maybe"`},
+		{"bad merge patch", Flags{MergePatch: "later"}, `invalid merge patch setting "later"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			_, err := Load(&tt.flags)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Package contactgen generates reproducible contact data from a seed and
+// loads it into the API, replacing load_contacts.sh. The same seed and
+// options always produce the same contacts, in the same order.
+package contactgen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+
+	"cpp-rest-api-tests/client"
+)
+
+type Options struct {
+	Seed   int64
+	Count  int
+	Locale string
+	// EmptyMiddleName is the share of contacts without a middle name.
+	EmptyMiddleName float64
+	// AreaCodes limits phone numbers to this many distinct area codes, so
+	// area-code queries match several contacts. Zero means unlimited.
+	AreaCodes int
+	// FixedAreaCode, when set, gives every phone number this area code
+	// instead of ones drawn from the locale, and AreaCodes is ignored.
+	FixedAreaCode string
+	// DuplicateName is the share of contacts reusing the first and last
+	// name of an earlier contact.
+	DuplicateName float64
+}
+
+// DefaultOptions mirrors load_contacts.sh: 100 US contacts, no middle
+// names, every phone in the 555 area code.
+func DefaultOptions() Options {
+	return Options{Seed: 1, Count: 100, Locale: "en_US", EmptyMiddleName: 1, AreaCodes: 1, FixedAreaCode: "555"}
+}
+
+// Generate returns opts.Count contacts.
+func Generate(opts Options) ([]client.Contact, error) {
+	if opts.Locale == "" {
+		opts.Locale = "en_US"
+	}
+	loc, ok := Locales[opts.Locale]
+	if !ok {
+		return nil, fmt.Errorf("unknown locale %q (known: %s)", opts.Locale, strings.Join(LocaleNames(), ", "))
+	}
+	if opts.EmptyMiddleName < 0 || opts.EmptyMiddleName > 1 || opts.DuplicateName < 0 || opts.DuplicateName > 1 {
+		return nil, fmt.Errorf("rates must be between 0 and 1")
+	}
+	if opts.FixedAreaCode != "" && !isAreaCode(opts.FixedAreaCode) {
+		return nil, fmt.Errorf("area code %q is not 3 digits", opts.FixedAreaCode)
+	}
+
+	r := rand.New(rand.NewSource(opts.Seed))
+	var areaCodes []string
+	switch {
+	case opts.FixedAreaCode != "":
+		areaCodes = []string{opts.FixedAreaCode}
+	case opts.AreaCodes > 0:
+		for i := 0; i < opts.AreaCodes; i++ {
+			areaCodes = append(areaCodes, loc.AreaCode(r))
+		}
+	}
+
+	contacts := make([]client.Contact, 0, opts.Count)
+	for i := 0; i < opts.Count; i++ {
+		c := client.Contact{
+			FirstName: pick(r, loc.FirstNames),
+			LastName:  pick(r, loc.LastNames),
+			Street:    fmt.Sprintf("%s %d", pick(r, loc.Streets), r.Intn(1000)+1),
+			City:      pick(r, loc.Cities),
+			State:     pick(r, loc.States),
+			Zip:       loc.Zip(r),
+		}
+		if len(contacts) > 0 && r.Float64() < opts.DuplicateName {
+			prev := contacts[r.Intn(len(contacts))]
+			c.FirstName, c.LastName = prev.FirstName, prev.LastName
+		}
+		if r.Float64() >= opts.EmptyMiddleName {
+			c.MiddleName = pick(r, loc.MiddleNames)
+		}
+		area := loc.AreaCode(r)
+		if len(areaCodes) > 0 {
+			area = pick(r, areaCodes)
+		}
+		c.Phone = fmt.Sprintf("%s%03d%04d", area, r.Intn(900)+100, r.Intn(9000)+1000)
+		c.Email = strings.ToLower(fmt.Sprintf("%s.%s@%s", c.FirstName, c.LastName, loc.EmailDomain))
+		contacts = append(contacts, c)
+	}
+	return contacts, nil
+}
+
+func isAreaCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pick(r *rand.Rand, values []string) string {
+	return values[r.Intn(len(values))]
+}
+
+// Manifest records which generated contacts were created under which ID.
+type Manifest struct {
+	Seed    int64    `json:"seed"`
+	Locale  string   `json:"locale"`
+	Count   int      `json:"count"`
+	Created []Entry  `json:"created"`
+	Failed  []Failed `json:"failed,omitempty"`
+}
+
+type Entry struct {
+	Index   int            `json:"index"`
+	ID      int            `json:"id"`
+	Contact client.Contact `json:"contact"`
+}
+
+type Failed struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// IDs lists the created IDs in generation order.
+func (m *Manifest) IDs() []int {
+	ids := make([]int, len(m.Created))
+	for i, e := range m.Created {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+// Load creates contacts with at most concurrency requests in flight and
+// writes one progress line per contact to progress, if it is not nil.
+// Entries in the manifest are in generation order regardless of the order
+// the server answered in.
+func Load(ctx context.Context, api *client.Client, contacts []client.Contact, concurrency int, progress io.Writer) *Manifest {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]*Entry, len(contacts))
+	failures := make([]string, len(contacts))
+
+	var mu sync.Mutex
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				created, err := api.Create(ctx, contacts[i])
+				mu.Lock()
+				if err != nil {
+					failures[i] = err.Error()
+					if progress != nil {
+						fmt.Fprintf(progress, "Failed to create contact %d: %v\n", i+1, err)
+					}
+				} else {
+					results[i] = &Entry{Index: i, ID: created.ID, Contact: created}
+					if progress != nil {
+						fmt.Fprintf(progress, "Contact %d created: %s %s (ID %d)\n", i+1, created.FirstName, created.LastName, created.ID)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range contacts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	m := &Manifest{Count: len(contacts)}
+	for i := range contacts {
+		if results[i] != nil {
+			m.Created = append(m.Created, *results[i])
+		} else {
+			m.Failed = append(m.Failed, Failed{Index: i, Error: failures[i]})
+		}
+	}
+	return m
+}
+
+func (m *Manifest) WriteFile(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %v", path, err)
+	}
+	return &m, nil
+}
+
+// Verify checks that every contact in the manifest exists with the
+// recorded fields.
+func (m *Manifest) Verify(ctx context.Context, api *client.Client) error {
+	for _, e := range m.Created {
+		got, err := api.Get(ctx, e.ID)
+		if err != nil {
+			return fmt.Errorf("contact %d (ID %d): %v", e.Index+1, e.ID, err)
+		}
+		if got != e.Contact {
+			return fmt.Errorf("contact %d (ID %d) changed: have %+v, manifest has %+v", e.Index+1, e.ID, got, e.Contact)
+		}
+	}
+	return nil
+}
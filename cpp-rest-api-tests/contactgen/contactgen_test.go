@@ -0,0 +1,96 @@
+package contactgen
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/refapi"
+)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	opts := Options{Seed: 9, Count: 50, Locale: "en_GB", EmptyMiddleName: 0.5, AreaCodes: 2, DuplicateName: 0.2}
+	a, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Generate(opts)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("same seed produced different contacts")
+	}
+	opts.Seed = 10
+	c, _ := Generate(opts)
+	if reflect.DeepEqual(a, c) {
+		t.Fatal("different seeds produced the same contacts")
+	}
+}
+
+func TestGenerateDistributions(t *testing.T) {
+	contacts, err := Generate(Options{Seed: 1, Count: 500, EmptyMiddleName: 0.3, AreaCodes: 3, DuplicateName: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	areas := map[string]bool{}
+	empty := 0
+	for _, c := range contacts {
+		areas[c.Phone[:3]] = true
+		if c.MiddleName == "" {
+			empty++
+		}
+	}
+	if len(areas) > 3 {
+		t.Errorf("expected at most 3 area codes, got %d", len(areas))
+	}
+	if empty < 100 || empty > 200 {
+		t.Errorf("expected about 150 empty middle names, got %d", empty)
+	}
+
+	defaults, _ := Generate(DefaultOptions())
+	for _, c := range defaults {
+		if c.Phone[:3] != "555" || c.MiddleName != "" || len(c.Zip) != 5 {
+			t.Fatalf("default options should match load_contacts.sh, got %+v", c)
+		}
+	}
+}
+
+func TestGenerateRejectsBadOptions(t *testing.T) {
+	if _, err := Generate(Options{Count: 1, Locale: "xx_XX"}); err == nil {
+		t.Error("unknown locale should fail")
+	}
+	if _, err := Generate(Options{Count: 1, DuplicateName: 2}); err == nil {
+		t.Error("rate above 1 should fail")
+	}
+	if _, err := Generate(Options{Count: 1, FixedAreaCode: "55"}); err == nil {
+		t.Error("a fixed area code that is not 3 digits should fail")
+	}
+}
+
+func TestLoadWritesManifestInOrder(t *testing.T) {
+	srv, _ := refapi.NewServer()
+	defer srv.Close()
+	api := client.New(srv.URL, nil)
+
+	contacts, _ := Generate(Options{Seed: 3, Count: 40})
+	m := Load(context.Background(), api, contacts, 6, nil)
+	if len(m.Created) != 40 || len(m.Failed) != 0 {
+		t.Fatalf("created %d, failed %d", len(m.Created), len(m.Failed))
+	}
+	for i, e := range m.Created {
+		if e.Index != i || e.Contact.FirstName != contacts[i].FirstName {
+			t.Fatalf("entry %d out of order: %+v", i, e)
+		}
+	}
+	if err := m.Verify(context.Background(), api); err != nil {
+		t.Fatal(err)
+	}
+
+	path := t.TempDir() + "/manifest.json"
+	if err := m.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadManifest(path)
+	if err != nil || !reflect.DeepEqual(read.IDs(), m.IDs()) {
+		t.Fatalf("manifest did not round-trip: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package contactgen
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Locale holds the value pools and formats for one region.
+type Locale struct {
+	FirstNames  []string
+	MiddleNames []string
+	LastNames   []string
+	Streets     []string
+	Cities      []string
+	States      []string
+	EmailDomain string
+	// AreaCode returns a three-digit area code; Zip a postal code.
+	AreaCode func(r *rand.Rand) string
+	Zip      func(r *rand.Rand) string
+}
+
+// Locales lists the built-in locales. "en_US" uses the same pools as
+// load_contacts.sh.
+var Locales = map[string]Locale{
+	"en_US": {
+		FirstNames:  []string{"John", "Jane", "Michael", "Emily", "David", "Sarah", "James", "Laura", "Robert", "Lisa"},
+		MiddleNames: []string{"Ann", "Lee", "Marie", "Ray", "Lynn", "James"},
+		LastNames:   []string{"Smith", "Johnson", "Brown", "Taylor", "Wilson", "Davis", "Clark", "Harris", "Lewis", "Walker"},
+		Streets:     []string{"Main St", "Park Ave", "Oak Rd", "Cedar Ln", "Maple Dr", "Elm St", "Pine Rd", "Birch Ave", "Spruce Ln", "Walnut Dr"},
+		Cities:      []string{"Anytown", "Springfield", "Riverside", "Greenville", "Fairview", "Lakewood", "Hillcrest", "Brookside"},
+		States:      []string{"CA", "NY", "TX", "FL", "PA", "IL", "OH", "GA"},
+		EmailDomain: "example.com",
+		AreaCode:    func(r *rand.Rand) string { return fmt.Sprintf("%d", r.Intn(800)+200) },
+		Zip:         func(r *rand.Rand) string { return fmt.Sprintf("%05d", r.Intn(90000)+10000) },
+	},
+	"en_GB": {
+		FirstNames:  []string{"Oliver", "Amelia", "Harry", "Isla", "George", "Ava", "Jack", "Mia", "Charlie", "Grace"},
+		MiddleNames: []string{"Rose", "James", "Mae", "Alexander", "Louise"},
+		LastNames:   []string{"Smith", "Jones", "Williams", "Taylor", "Davies", "Evans", "Thomas", "Roberts", "Walker", "Wright"},
+		Streets:     []string{"High St", "Station Rd", "Church Ln", "Victoria Rd", "Green Ln", "Manor Rd"},
+		Cities:      []string{"London", "Leeds", "Bristol", "York", "Bath", "Oxford", "Cardiff"},
+		States:      []string{"ENG", "SCT", "WLS", "NIR"},
+		EmailDomain: "example.co.uk",
+		AreaCode:    func(r *rand.Rand) string { return fmt.Sprintf("0%d", r.Intn(90)+10) },
+		Zip: func(r *rand.Rand) string {
+			letters := "ABCDEFGHJKLMNPRSTUWXYZ"
+			return fmt.Sprintf("%c%c%d %d%c%c", letters[r.Intn(len(letters))], letters[r.Intn(len(letters))], r.Intn(20)+1, r.Intn(10), letters[r.Intn(len(letters))], letters[r.Intn(len(letters))])
+		},
+	},
+	"de_DE": {
+		FirstNames:  []string{"Lukas", "Anna", "Leon", "Lena", "Finn", "Marie", "Jonas", "Sophie", "Paul", "Jürgen"},
+		MiddleNames: []string{"Maria", "Johann", "Luise", "Friedrich"},
+		LastNames:   []string{"Müller", "Schmidt", "Schneider", "Fischer", "Weber", "Meyer", "Wagner", "Becker"},
+		Streets:     []string{"Hauptstraße", "Schulstraße", "Gartenweg", "Bahnhofstraße", "Dorfstraße"},
+		Cities:      []string{"Berlin", "Hamburg", "München", "Köln", "Leipzig", "Dresden"},
+		States:      []string{"BE", "HH", "BY", "NW", "SN"},
+		EmailDomain: "example.de",
+		AreaCode:    func(r *rand.Rand) string { return fmt.Sprintf("0%d", r.Intn(90)+10) },
+		Zip:         func(r *rand.Rand) string { return fmt.Sprintf("%05d", r.Intn(98000)+1000) },
+	},
+}
+
+// LocaleNames returns the built-in locale names, sorted.
+func LocaleNames() []string {
+	names := make([]string, 0, len(Locales))
+	for name := range Locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,129 @@
+package contactgen
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cucumber/godog"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/scenario"
+)
+
+// Steps exposes generated data loads to godog scenarios. The manifest
+// steps check either the load made in the scenario or a manifest file,
+// such as the one cmd/loadcontacts writes.
+type Steps struct {
+	api      *client.Client
+	manifest *Manifest
+	written  []string
+}
+
+func NewSteps(api *client.Client) *Steps {
+	return &Steps{api: api}
+}
+
+func (s *Steps) Register(ctx scenario.Context) {
+	ctx.Step(`^I load (\d+) generated contacts with seed (\d+)$`, s.iLoadGeneratedContacts)
+	ctx.Step(`^I load (\d+) generated "([^"]*)" contacts with seed (\d+)$`, s.iLoadGeneratedLocaleContacts)
+	ctx.Step(`^I write the manifest to "([^"]*)"$`, s.iWriteTheManifestTo)
+	ctx.Step(`^the manifest "([^"]*)"$`, s.theManifest)
+	ctx.Step(`^the manifest should list (\d+) created contacts$`, s.theManifestShouldListCreatedContacts)
+	ctx.Step(`^the manifest IDs should run from (\d+) to (\d+)$`, s.theManifestIDsShouldRunFromTo)
+	ctx.Step(`^every contact in the manifest should exist$`, s.everyContactInTheManifestShouldExist)
+	ctx.After(s.removeWritten)
+}
+
+func (s *Steps) load(opts Options) error {
+	contacts, err := Generate(opts)
+	if err != nil {
+		return err
+	}
+	s.manifest = Load(context.Background(), s.api, contacts, 8, nil)
+	s.manifest.Seed = opts.Seed
+	s.manifest.Locale = opts.Locale
+	if len(s.manifest.Failed) > 0 {
+		return fmt.Errorf("%d of %d contacts failed to load, first: %s", len(s.manifest.Failed), len(contacts), s.manifest.Failed[0].Error)
+	}
+	return nil
+}
+
+func (s *Steps) iLoadGeneratedContacts(count int, seed int64) error {
+	opts := DefaultOptions()
+	opts.Count, opts.Seed = count, seed
+	return s.load(opts)
+}
+
+func (s *Steps) iLoadGeneratedLocaleContacts(count int, locale string, seed int64) error {
+	opts := DefaultOptions()
+	opts.Count, opts.Seed, opts.Locale = count, seed, locale
+	opts.FixedAreaCode, opts.AreaCodes = "", 3
+	return s.load(opts)
+}
+
+// iWriteTheManifestTo writes the scenario's manifest as cmd/loadcontacts
+// would. The file is removed when the scenario ends.
+func (s *Steps) iWriteTheManifestTo(path string) error {
+	if s.manifest == nil {
+		return fmt.Errorf("no contacts have been loaded in this scenario")
+	}
+	if err := s.manifest.WriteFile(path); err != nil {
+		return fmt.Errorf("failed to write manifest: %v", err)
+	}
+	s.written = append(s.written, path)
+	return nil
+}
+
+// theManifest replaces the scenario's manifest with the one in path,
+// relative to the working directory.
+func (s *Steps) theManifest(path string) error {
+	m, err := ReadManifest(path)
+	if err != nil {
+		return err
+	}
+	s.manifest = m
+	return nil
+}
+
+func (s *Steps) removeWritten(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+	for _, path := range s.written {
+		os.Remove(path)
+	}
+	s.written = nil
+	return ctx, err
+}
+
+func (s *Steps) theManifestShouldListCreatedContacts(count int) error {
+	if s.manifest == nil {
+		return fmt.Errorf("no contacts have been loaded in this scenario")
+	}
+	if len(s.manifest.Created) != count {
+		return fmt.Errorf("expected %d created contacts in the manifest, got %d", count, len(s.manifest.Created))
+	}
+	return nil
+}
+
+func (s *Steps) theManifestIDsShouldRunFromTo(from, to int) error {
+	if s.manifest == nil {
+		return fmt.Errorf("no contacts have been loaded in this scenario")
+	}
+	seen := map[int]bool{}
+	for _, id := range s.manifest.IDs() {
+		if id < from || id > to || seen[id] {
+			return fmt.Errorf("manifest ID %d is outside %d..%d or repeated", id, from, to)
+		}
+		seen[id] = true
+	}
+	if len(seen) != to-from+1 {
+		return fmt.Errorf("expected %d IDs from %d to %d, got %d", to-from+1, from, to, len(seen))
+	}
+	return nil
+}
+
+func (s *Steps) everyContactInTheManifestShouldExist() error {
+	if s.manifest == nil {
+		return fmt.Errorf("no contacts have been loaded in this scenario")
+	}
+	return s.manifest.Verify(context.Background(), s.api)
+}
@@ -0,0 +1,161 @@
+// Package difftest replays one generated sequence of API calls against two
+// implementations of the contacts API and reports the first response on
+// which they disagree.
+package difftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"cpp-rest-api-tests/jsondiff"
+)
+
+// Response is what one target answered to a step.
+type Response struct {
+	Status int
+	Body   string
+}
+
+// Divergence describes the first step on which the targets disagreed.
+type Divergence struct {
+	Step   Step
+	A, B   Response
+	Reason string
+	Diff   string
+	// Trace holds the steps sent before the divergent one.
+	Trace []Step
+}
+
+func (d *Divergence) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s diverged: %s\n", d.Step, d.Reason)
+	fmt.Fprintf(&b, "  A: %d %s\n", d.A.Status, d.A.Body)
+	fmt.Fprintf(&b, "  B: %d %s\n", d.B.Status, d.B.Body)
+	if d.Diff != "" {
+		fmt.Fprintf(&b, "response mismatch (-A +B):\n%s\n", d.Diff)
+	}
+	return b.String()
+}
+
+// Runner sends every step to both A and B.
+type Runner struct {
+	A, B   string
+	Client *http.Client
+	// Reset clears both targets with DELETE /reset before the run so the
+	// ID counters start level.
+	Reset bool
+}
+
+// Run replays n generated steps. It returns a *Divergence as the error when
+// the targets disagree, or a plain error when a target cannot be reached.
+func (r *Runner) Run(gen *Generator, n int) error {
+	if r.Client == nil {
+		r.Client = http.DefaultClient
+	}
+	if r.Reset {
+		for _, base := range []string{r.A, r.B} {
+			if _, err := r.send(base, Step{Method: "DELETE", Path: "/reset"}); err != nil {
+				return err
+			}
+		}
+	}
+
+	var trace []Step
+	for i := 0; i < n; i++ {
+		step := gen.Next()
+		a, err := r.send(r.A, step)
+		if err != nil {
+			return fmt.Errorf("%s against A: %v", step, err)
+		}
+		b, err := r.send(r.B, step)
+		if err != nil {
+			return fmt.Errorf("%s against B: %v", step, err)
+		}
+		if d := compare(step, a, b); d != nil {
+			d.Trace = trace
+			return d
+		}
+		trace = append(trace, step)
+		learn(gen, step, a)
+	}
+	return nil
+}
+
+func (r *Runner) send(base string, step Step) (Response, error) {
+	var body io.Reader
+	if step.Body != "" {
+		body = strings.NewReader(step.Body)
+	}
+	req, err := http.NewRequest(step.Method, strings.TrimRight(base, "/")+step.Path, body)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to create request: %v", err)
+	}
+	if step.Body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to read response: %v", err)
+	}
+	return Response{Status: resp.StatusCode, Body: string(data)}, nil
+}
+
+func compare(step Step, a, b Response) *Divergence {
+	d := &Divergence{Step: step, A: a, B: b}
+	if a.Status != b.Status {
+		d.Reason = fmt.Sprintf("status %d vs %d", a.Status, b.Status)
+		return d
+	}
+	if step.Method == "POST" && a.Status == http.StatusCreated {
+		idA, idB := recordID(a.Body), recordID(b.Body)
+		if idA != idB {
+			d.Reason = fmt.Sprintf("assigned ID %d vs %d", idA, idB)
+			return d
+		}
+	}
+	ca, okA := jsondiff.Canonical([]byte(a.Body))
+	cb, okB := jsondiff.Canonical([]byte(b.Body))
+	switch {
+	case okA && okB:
+		if ca != cb {
+			d.Reason = "JSON bodies differ"
+			d.Diff = jsondiff.Diff(ca, cb)
+			return d
+		}
+	case a.Body != b.Body:
+		d.Reason = "bodies differ"
+		d.Diff = jsondiff.Diff(a.Body, b.Body)
+		return d
+	}
+	return nil
+}
+
+// learn feeds A's answer back into the generator; B agreed with it.
+func learn(gen *Generator, step Step, resp Response) {
+	switch {
+	case step.Method == "POST" && resp.Status == http.StatusCreated:
+		gen.Created(recordID(resp.Body))
+	case step.Method == "DELETE" && step.Path == "/reset":
+		gen.Reset()
+	case step.Method == "DELETE" && resp.Status == http.StatusNoContent:
+		var id int
+		fmt.Sscanf(step.Path, "/records/%d", &id)
+		gen.Deleted(id)
+	}
+}
+
+func recordID(body string) int {
+	var r struct {
+		ID int `json:"id"`
+	}
+	json.Unmarshal([]byte(body), &r)
+	return r.ID
+}
@@ -0,0 +1,53 @@
+package difftest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cpp-rest-api-tests/refapi"
+)
+
+func TestIdenticalImplementationsAgree(t *testing.T) {
+	a, _ := refapi.NewServer()
+	defer a.Close()
+	b, _ := refapi.NewServer()
+	defer b.Close()
+
+	for seed := int64(1); seed <= 5; seed++ {
+		runner := &Runner{A: a.URL, B: b.URL, Reset: true}
+		if err := runner.Run(NewGenerator(seed), 300); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+	}
+}
+
+func TestReportsFirstDivergence(t *testing.T) {
+	a, _ := refapi.NewServer()
+	defer a.Close()
+
+	// B capitalises first_name before querying, so "john" finds "John".
+	ref := refapi.NewHandler()
+	b := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Has("first_name") {
+			if name := q.Get("first_name"); name != "" {
+				q.Set("first_name", strings.ToUpper(name[:1])+name[1:])
+			}
+			r.URL.RawQuery = q.Encode()
+		}
+		ref.ServeHTTP(w, r)
+	}))
+	defer b.Close()
+
+	runner := &Runner{A: a.URL, B: b.URL, Reset: true}
+	err := runner.Run(NewGenerator(1), 2000)
+	var d *Divergence
+	if !errors.As(err, &d) {
+		t.Fatalf("expected a divergence, got %v", err)
+	}
+	if !strings.Contains(d.Step.Path, "first_name=") || d.Diff == "" {
+		t.Fatalf("unexpected divergence report:\n%s", d.Error())
+	}
+}
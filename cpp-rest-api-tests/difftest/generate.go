@@ -0,0 +1,159 @@
+package difftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"strings"
+)
+
+// Step is one HTTP call in a generated sequence.
+type Step struct {
+	Index  int
+	Method string
+	Path   string
+	Body   string
+}
+
+func (s Step) String() string {
+	if s.Body == "" {
+		return fmt.Sprintf("#%d %s %s", s.Index, s.Method, s.Path)
+	}
+	return fmt.Sprintf("#%d %s %s %s", s.Index, s.Method, s.Path, s.Body)
+}
+
+// Small value pools so that queries and updates regularly hit existing
+// records instead of always coming back empty.
+var pools = map[string][]string{
+	"first_name":  {"John", "Jane", "Sarah", "john", ""},
+	"middle_name": {"", "Q", "Lee"},
+	"last_name":   {"Doe", "Smith", "Walker"},
+	"street":      {"123 Main St", "9 Oak Rd", ""},
+	"city":        {"Springfield", "Anytown", "springfield", ""},
+	"state":       {"CA", "NY", "TX"},
+	"zip":         {"12345", "90210", "02134"},
+	"phone":       {"5551234567", "5559876543", "1234567890", "555", "555123"},
+	"email":       {"john@example.com", "jane@example.com", ""},
+}
+
+var fields = []string{"first_name", "middle_name", "last_name", "street", "city", "state", "zip", "phone", "email"}
+
+// Generator produces a reproducible stream of API calls from a seed. It
+// learns the IDs handed out by the server so later calls can target
+// records that exist.
+type Generator struct {
+	rnd   *rand.Rand
+	ids   []int
+	index int
+}
+
+func NewGenerator(seed int64) *Generator {
+	return &Generator{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// Created tells the generator about an ID returned by POST /records.
+func (g *Generator) Created(id int) {
+	g.ids = append(g.ids, id)
+}
+
+// Deleted forgets an ID after a successful delete.
+func (g *Generator) Deleted(id int) {
+	for i, v := range g.ids {
+		if v == id {
+			g.ids = append(g.ids[:i], g.ids[i+1:]...)
+			return
+		}
+	}
+}
+
+// Reset forgets every ID after DELETE /reset.
+func (g *Generator) Reset() {
+	g.ids = nil
+}
+
+func (g *Generator) Next() Step {
+	g.index++
+	s := Step{Index: g.index}
+	switch n := g.rnd.Intn(100); {
+	case n < 30:
+		s.Method, s.Path, s.Body = "POST", "/records", g.body(true)
+	case n < 45:
+		s.Method, s.Path = "GET", fmt.Sprintf("/records/%s", g.id())
+	case n < 65:
+		s.Method, s.Path, s.Body = "PUT", fmt.Sprintf("/records/%s", g.id()), g.body(false)
+	case n < 75:
+		s.Method, s.Path = "DELETE", fmt.Sprintf("/records/%s", g.id())
+	case n < 77:
+		s.Method, s.Path = "DELETE", "/reset"
+	default:
+		s.Method, s.Path = "GET", "/records"+g.query()
+	}
+	return s
+}
+
+// id mostly picks a known ID, sometimes one that was never assigned and
+// occasionally something that is not a number at all.
+func (g *Generator) id() string {
+	switch n := g.rnd.Intn(20); {
+	case n == 0:
+		return []string{"0", "-1", "abc", "2147483648", "1x"}[g.rnd.Intn(5)]
+	case n < 3 || len(g.ids) == 0:
+		return fmt.Sprint(g.rnd.Intn(50) + 1)
+	default:
+		return fmt.Sprint(g.ids[g.rnd.Intn(len(g.ids))])
+	}
+}
+
+func (g *Generator) body(create bool) string {
+	switch g.rnd.Intn(25) {
+	case 0:
+		return `{"first_name": "unterminated`
+	case 1:
+		return `{"first_name": 42}`
+	case 2:
+		return `{"phone": null}`
+	case 3:
+		return `[]`
+	}
+	obj := map[string]interface{}{}
+	count := g.rnd.Intn(len(fields)) + 1
+	if !create {
+		count = g.rnd.Intn(3) + 1
+	}
+	for _, i := range g.rnd.Perm(len(fields))[:count] {
+		name := fields[i]
+		obj[name] = g.pick(name)
+	}
+	if g.rnd.Intn(10) == 0 {
+		obj["id"] = g.rnd.Intn(100)
+	}
+	if g.rnd.Intn(10) == 0 {
+		obj["nickname"] = "Jo"
+	}
+	data, _ := json.Marshal(obj)
+	return string(data)
+}
+
+func (g *Generator) query() string {
+	if g.rnd.Intn(5) == 0 {
+		return ""
+	}
+	q := url.Values{}
+	for _, i := range g.rnd.Perm(len(fields))[:g.rnd.Intn(3)+1] {
+		name := fields[i]
+		q.Set(name, g.pick(name))
+	}
+	if len(g.ids) > 0 && g.rnd.Intn(8) == 0 {
+		q.Set("id", fmt.Sprint(g.ids[g.rnd.Intn(len(g.ids))]))
+	}
+	if g.rnd.Intn(10) == 0 {
+		q.Set("nickname", "x")
+	}
+	return "?" + strings.ReplaceAll(q.Encode(), "+", "%20")
+}
+
+func (g *Generator) pick(field string) string {
+	pool := pools[field]
+	return pool[g.rnd.Intn(len(pool))]
+}
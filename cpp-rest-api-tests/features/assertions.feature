@@ -0,0 +1,52 @@
+Feature: Response assertions
+  Background:
+    Given the API is running
+    And the database should be empty
+    And a contact exists with first name "Sarah" and phone "5551234567"
+    And a contact exists with first name "Tom" and phone "4159876543"
+    And I send a POST request to "/records" with contact details:
+      """
+      {"first_name": "Ana", "last_name": "Ruiz", "phone": "5559990000", "state": "NV"}
+      """
+
+  Scenario: Address fields of a listing by path
+    When I send a GET request to "/records"
+    Then the response status code should be 200
+    And the response field "[0].first_name" should equal "Sarah"
+    And the response field "$[1].id" should equal "2"
+    And the response field "$[-1].id" should equal "{lastCreatedID}"
+    And the response field "[-1]" should equal:
+      """
+      {
+        "id": 3, "first_name": "Ana", "middle_name": "", "last_name": "Ruiz",
+        "phone": "5559990000", "email": "", "street": "", "city": "", "state": "NV", "zip": ""
+      }
+      """
+
+  Scenario: An area-code query returns only matching phones
+    When I send a GET request to "/records?phone=555"
+    Then the response status code should be 200
+    And the response should contain 2 contacts
+    And the response should match the table:
+      | first_name | phone      |
+      | Sarah      | 5551234567 |
+      | Ana        | 5559990000 |
+
+  Scenario: Every contact in a state
+    When I send a GET request to "/records?state=CA"
+    Then every contact in the response should have "state" equal to "CA"
+    And every contact in the response should have "city" equal to "Anytown"
+
+  Scenario: Compare a listing with a table
+    When I send a GET request to "/records"
+    Then the response should match the table:
+      | id | first_name | last_name | state |
+      | 1  | Sarah      | Doe       | CA    |
+      | 2  | Tom        | Doe       | CA    |
+      | 3  | Ana        | Ruiz      | NV    |
+
+  Scenario: A single contact is a one-row table
+    When I send a GET request to "/records/{lastCreatedID}"
+    Then the response should match the table:
+      | id               | first_name | phone      |
+      | {lastCreatedID}  | Ana        | 5559990000 |
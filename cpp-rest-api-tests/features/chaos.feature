@@ -0,0 +1,60 @@
+Feature: Fault injection
+  A chaos proxy sits between the client and the API and injects slowness
+  and failures, so that client timeouts and error handling can be tested.
+  Faults are chosen by a seeded generator, so the rates below repeat.
+
+  Requests go through the client package with the configured HTTP client,
+  as code using the API would send them. A raw request uses a bare client
+  instead, to tell a dropped, reset or truncated connection apart.
+
+  Background:
+    Given the API is running
+    And the database should be empty
+
+  Scenario: Without faults the proxy is transparent
+    Given no faults are injected
+    When I send 5 GET requests to "/records" through the chaos proxy
+    Then every request should get status 200
+    And the chaos proxy should have seen 5 requests
+
+  Scenario: A share of requests fail with 500
+    Given the API responds with 500 for 30% of requests
+    When I send 100 GET requests to "/records" through the chaos proxy
+    Then between 15 and 45 of the requests should get status 500
+    And between 55 and 85 of the requests should get status 200
+
+  Scenario: Faults can be limited to one route
+    Given the API responds with 503 on POST /records
+    When I send a GET request to "/records" through the chaos proxy
+    Then the request should get status 200
+    When I send a POST request to "/records" through the chaos proxy
+    Then the request should get status 503
+
+  Scenario: Latency on one route
+    Given latency of 200ms is injected on GET /records
+    When I send a GET request to "/records" through the chaos proxy
+    Then the request should get status 200
+    And the request should take at least 200ms
+
+  Scenario: The client gives up once its timeout has passed
+    Given the client timeout is 300ms
+    And latency longer than the client timeout is injected on GET /records
+    When I send a GET request to "/records" through the chaos proxy
+    Then the request should time out
+    And the client error should contain "failed to send GET request"
+
+  Scenario: The client reports a broken connection
+    Given connections are reset on GET /records
+    When I send a GET request to "/records" through the chaos proxy
+    Then the client error should contain "failed to send GET request"
+
+  Scenario Outline: Broken connections
+    Given <fault>
+    When I send a raw GET request to "/records" through the chaos proxy
+    Then the request should <outcome>
+
+    Examples:
+      | fault                                 | outcome      |
+      | connections are dropped               | be dropped   |
+      | connections are reset on GET /records | be reset     |
+      | response bodies are truncated         | be truncated |
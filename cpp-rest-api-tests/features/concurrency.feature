@@ -0,0 +1,28 @@
+@stress
+Feature: Concurrent access
+  main.cpp serves requests on four threads while sharing the records vector
+  and next_id, so these scenarios look for duplicate IDs, lost writes and
+  records that vanish under parallel load. They are expected to fail against
+  it and only run when asked for, e.g. with -godog.tags=@stress.
+
+  Background:
+    Given the API is running
+    And the database should be empty
+
+  Scenario: Parallel creates hand out unique IDs
+    When 50 clients concurrently create contacts
+    Then 50 contacts should have been created
+    And no concurrency invariant should be violated
+    When I send a GET request to "/records"
+    Then the response should contain 50 contacts
+
+  Scenario: Parallel creates, updates and deletes keep every write
+    When 20 clients concurrently create, update and delete contacts
+    Then 100 contacts should have been created
+    And no concurrency invariant should be violated
+    When I send a GET request to "/records"
+    Then the response should contain 60 contacts
+
+  Scenario: Resets racing creates leave a clean store
+    When 10 clients concurrently reset the store 40 times
+    Then no concurrency invariant should be violated
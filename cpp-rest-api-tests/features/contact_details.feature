@@ -0,0 +1,99 @@
+Feature: Contact details round trip
+  Background:
+    Given the API is running
+    And the database should be empty
+
+  Scenario: Create a contact and read it back
+    When I send a POST request to "/records" with contact details:
+      """
+      {
+        "first_name": "John",
+        "last_name": "Doe",
+        "phone": "1234567890",
+        "email": "john@example.com",
+        "street": "123 Main St",
+        "city": "Anytown",
+        "state": "CA",
+        "zip": "12345"
+      }
+      """
+    Then the response status code should be 201
+    And the response should contain the contact ID
+    When I send a GET request to "/records/{lastCreatedID}"
+    Then the response status code should be 200
+    And the response should contain the contact details:
+      """
+      {
+        "id": {lastCreatedID},
+        "first_name": "John",
+        "last_name": "Doe",
+        "middle_name": "",
+        "phone": "1234567890",
+        "email": "john@example.com",
+        "street": "123 Main St",
+        "city": "Anytown",
+        "state": "CA",
+        "zip": "12345"
+      }
+      """
+
+  Scenario: Query by first name and phone
+    Given a contact exists with first name "Tom" and phone "4159876543"
+    And a contact exists with first name "Sarah" and phone "5551234567"
+    When I send a GET request to "/records?first_name=Sarah&phone=555"
+    Then the response status code should be 200
+    And the response should contain a list with the contact:
+      """
+      [
+        {
+          "id": {lastCreatedID},
+          "first_name": "Sarah",
+          "last_name": "Doe",
+          "middle_name": "",
+          "phone": "5551234567",
+          "email": "john@example.com",
+          "street": "123 Main St",
+          "city": "Anytown",
+          "state": "CA",
+          "zip": "12345"
+        }
+      ]
+      """
+
+  Scenario: Update a contact
+    Given a contact exists with ID 1
+    When I send a PUT request to "/records/{lastCreatedID}" with updated details:
+      """
+      {"first_name": "Jane", "city": "Springfield"}
+      """
+    Then the response status code should be 200
+    And the response should contain the updated contact details:
+      """
+      {
+        "id": {lastCreatedID},
+        "first_name": "Jane",
+        "last_name": "Doe",
+        "middle_name": "",
+        "phone": "1234567890",
+        "email": "john@example.com",
+        "street": "123 Main St",
+        "city": "Springfield",
+        "state": "CA",
+        "zip": "12345"
+      }
+      """
+
+  Scenario: Delete a contact
+    Given a contact exists with ID 1
+    And a contact exists with ID {lastCreatedID}
+    When I send a DELETE request to "/records/{lastCreatedID}"
+    Then the response status code should be 204
+    And a subsequent GET request to "/records/{lastCreatedID}" should return 404
+
+  Scenario: Reset the store
+    Given I have created 3 contacts
+    When I send a DELETE request to reset
+    Then the response status code should be 204
+    And a subsequent GET request to "/records" should return 200
+    When I send a GET request to "/records"
+    Then the response should contain 0 contacts
@@ -13,7 +13,7 @@ Feature: Contact Management API
       }
       """
     Then the response status code should be 201
-    And the response should contain "John"
+    And the response field "first_name" should equal "John"
   Scenario: Retrieve all contacts
     Given I have created 2 contacts
     When I send a GET request to "/records"
@@ -23,7 +23,8 @@ Feature: Contact Management API
     Given I have created a contact with ID 1
     When I send a GET request to "/records/{lastCreatedID}"
     Then the response status code should be 200
-    And the response should contain "John"
+    And the response field "id" should equal "{lastCreatedID}"
+    And the response field "first_name" should equal "John"
   Scenario: Update existing contact
     Given I have created a contact with ID 1
     When I send a PUT request to "/records/{lastCreatedID}" with updated details:
@@ -33,7 +34,8 @@ Feature: Contact Management API
       }
       """
     Then the response status code should be 200
-    And the response should contain "Jane"
+    And the response field "first_name" should equal "Jane"
+    And the response field "last_name" should equal "Doe"
   Scenario: Delete existing contact
     Given I have created a contact with ID 1
     When I send a DELETE request to "/records/{lastCreatedID}"
@@ -0,0 +1,60 @@
+Feature: Response headers
+  main.cpp answers with plain send() calls: no Content-Type, the request
+  Content-Type and Accept are ignored, and unbound methods get 404. These
+  scenarios pin that behaviour; the matrix lists it as gaps.
+
+  Background:
+    Given the API is running
+    And the database should be empty
+
+  Scenario: A record is sent with a correct length but no Content-Type
+    Given a contact exists with first name "Ann" and phone "5551234567"
+    When I send a GET request to "/records/{lastCreatedID}"
+    Then the response status code should be 200
+    And the response Content-Length should match the body
+    And the response should not have a "Content-Type" header
+
+  Scenario: Error bodies are plain text without a Content-Type
+    When I send a GET request to "/records/42"
+    Then the response status code should be 404
+    And the response Content-Length should match the body
+    And the response should not have a "Content-Type" header
+
+  Scenario Outline: The request Content-Type is ignored
+    Given I set the request header "Content-Type" to "<type>"
+    When I send a POST request to "/records" with contact details:
+      """
+      {"first_name": "Ann"}
+      """
+    Then the response status code should be 201
+    And the response field "first_name" should equal "Ann"
+
+    Examples:
+      | type                              |
+      | text/plain                        |
+      | application/x-www-form-urlencoded |
+      | application/json; charset=utf-8   |
+
+  Scenario: A body without a Content-Type is parsed as JSON
+    Given I send requests without a "Content-Type" header
+    When I send a POST request to "/records" with contact details:
+      """
+      {"first_name": "Ann"}
+      """
+    Then the response status code should be 201
+
+  Scenario: Accept is not negotiated
+    Given I set the request header "Accept" to "text/html"
+    When I send a GET request to "/records"
+    Then the response status code should be 200
+    And the response should contain 0 contacts
+
+  Scenario: Conformance matrix
+    When I run the header conformance checks
+    Then the header conformance matrix should have no failures
+    And the header check "content-length" should be "pass" for every operation
+    And the header check "content-type" should be "gap" for "getRecord"
+    And the header check "request-type-wrong" should be "gap" for "createRecord"
+    And the header check "accept-unsupported" should be "gap" for "queryRecords"
+    And the header check "allow-on-405" should be "gap" for every operation
+    And the header check "cors" should be "n/a" for every operation
@@ -0,0 +1,33 @@
+Feature: Persistence across restarts
+  main.cpp keeps records in a std::vector, so a restart loses them and
+  next_id starts again at 1. The scenarios tagged @expected-fail prove
+  durability; they are expected to fail until a storage backend lands, and
+  fail the suite once they pass so that the tag is removed. Restarts need a
+  suite-managed API (the managed or reference profile); against any other
+  target these scenarios are skipped.
+
+  Background:
+    Given the API is running
+    And the database should be empty
+
+  Scenario: The API answers again after a restart
+    Given contact {alice} exists with first name "Alice"
+    When the API is restarted
+    Then the API is running
+
+  @durability @expected-fail
+  Scenario: Contacts survive a restart
+    Given contact {alice} exists with first name "Alice"
+    And contact {bob} exists with first name "Bob"
+    When the API is restarted
+    Then contact {alice} should still exist
+    And contact {bob} should still exist
+    And every contact should survive the restart
+
+  @durability @expected-fail
+  Scenario: IDs are not reused after a restart
+    Given contact {alice} exists with first name "Alice"
+    And contact {bob} exists with first name "Bob"
+    And I send a DELETE request to "/records/{bob}"
+    When the API is restarted
+    Then a new contact should not reuse an ID from before the restart
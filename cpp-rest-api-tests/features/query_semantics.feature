@@ -0,0 +1,80 @@
+Feature: Query semantics
+  GET /records filters the contacts created in this order:
+
+    | first | last     | city        | state | zip   | phone      | email              |
+    | Ada   | Lovelace | Springfield | IL    | 62701 | 5551234567 | ada@example.com    |
+    | Alan  | Turing   | springfield |       |       | 5559876543 |                    |
+    | Grace | Hopper   | Springfield |       |       | 4441234567 |                    |
+    | Linus |          |             |       |       | 555        |                    |
+    | Margaret | Hamilton | SPRINGFIELD |    |       | 55512      |                    |
+    | Edsger | Dijkstra | Shelbyville |     |       | 5551234567 | EDSGER@example.com |
+    | Barbara | Liskov |             |       |       |            |                    |
+
+  Grace alone has a middle name, Brewster. Contacts are named by their
+  first name; results are listed in creation order.
+
+  Background:
+    Given the API is running
+    And the query semantics dataset is loaded
+
+  Scenario: The undocumented id parameter is compared as a string
+    Then these queries should return:
+      | query                 | returns |
+      | ?id=1                 | Ada     |
+      | ?id=6                 | Edsger  |
+      | ?id=01                | nothing |
+      | ?id=abc               | nothing |
+      | ?id=99                | nothing |
+      | ?id=1&first_name=Alan | nothing |
+
+  Scenario: Fields match exactly, never as substrings
+    Then these queries should return:
+      | query                  | returns     |
+      | ?city=Springfield      | Ada, Grace  |
+      | ?city=Spring           | nothing     |
+      | ?last_name=Love        | nothing     |
+      | ?email=ada@example.com | Ada         |
+
+  Scenario: Matching is case-sensitive
+    Then these queries should return:
+      | query                     | returns  |
+      | ?city=springfield         | Alan     |
+      | ?city=SPRINGFIELD         | Margaret |
+      | ?first_name=ada           | nothing  |
+      | ?email=edsger@example.com | nothing  |
+
+  Scenario: A 3-character phone is an area code, any other length the whole number
+    Then these queries should return:
+      | query                  | returns                            |
+      | ?phone=555             | Ada, Alan, Linus, Margaret, Edsger |
+      | ?phone=444             | Grace                              |
+      | ?phone=55              | nothing                            |
+      | ?phone=5551            | nothing                            |
+      | ?phone=55512           | Margaret                           |
+      | ?phone=555123456       | nothing                            |
+      | ?phone=5551234567      | Ada, Edsger                        |
+      | ?phone=234             | nothing                            |
+
+  Scenario: An empty value matches only blank fields
+    Then querying "?city=" should return "Linus, Barbara"
+    And querying "?phone=" should return "Barbara"
+    And querying "?first_name=" should return nothing
+    And querying "?middle_name=" should return "Ada, Alan, Linus, Margaret, Edsger, Barbara"
+
+  Scenario: Unknown parameters are ignored
+    Then querying "?nickname=x" should return everyone
+    And querying "?City=Springfield" should return everyone
+    And querying "?nickname=x&city=Springfield" should return "Ada, Grace"
+
+  Scenario: Parameters combine with AND
+    Then these queries should return:
+      | query                              | returns |
+      | ?city=Springfield&phone=555        | Ada     |
+      | ?city=Springfield&phone=444        | Grace   |
+      | ?first_name=Ada&last_name=Turing   | nothing |
+
+  Scenario: A repeated parameter uses its first value
+    Then querying "?city=Shelbyville&city=Springfield" should return "Edsger"
+
+  Scenario: Every reference case
+    Then every query semantics case should hold
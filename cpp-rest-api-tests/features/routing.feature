@@ -0,0 +1,53 @@
+Feature: Routing
+  main.cpp binds six routes. Every other method and path is answered by the
+  Pistache router with 404 "Could not find a matching route", shown as "-"
+  below; "404" is ApiHandler's "Record not found". Each request is sent to
+  a store holding one contact with ID 1.
+
+  The statuses are read from main.cpp and Pistache's source and have not
+  been measured against a build yet. Record them with
+  go test -v ./routing -run TestMatrix -args -contacts.profile=managed -routing.record
+
+  Background:
+    Given the API is running
+    And the database should be empty
+
+  Scenario: Every method on every known path
+    Then every method sent to every path should return:
+      | path        | GET | POST | PUT | DELETE | PATCH | OPTIONS |
+      | /records    | 200 | 201  | -   | -      | -     | -       |
+      | /records/1  | 200 | -    | 200 | 204    | -     | -       |
+      | /records/99 | 404 | -    | 404 | 404    | -     | -       |
+      | /reset      | -   | -    | -   | 204    | -     | -       |
+
+  Scenario: Trailing and doubled slashes are ignored
+    Then every method sent to every path should return:
+      | path        | GET | POST | PUT | DELETE |
+      | /records/   | 200 | 201  | -   | -      |
+      | //records   | 200 | 201  | -   | -      |
+      | /records/1/ | 200 | -    | 200 | 204    |
+      | /reset/     | -   | -    | -   | 204    |
+
+  Scenario: IDs that are not positive integers find no record
+    Then every method sent to every path should return:
+      | path        | GET | PUT | DELETE | PATCH |
+      | /records/0  | 404 | 404 | 404    | -     |
+      | /records/-1 | 404 | 404 | 404    | -     |
+
+  Scenario: An ID that is not an int is a server error
+    as<int>() throws "Bad lexical cast" and the router answers 500 with it.
+
+    Then every method sent to every path should return:
+      | path         | GET | PUT | DELETE | PATCH |
+      | /records/abc | 500 | 500 | 500    | -     |
+
+  Scenario: Unknown paths
+    Then every method sent to every path should return:
+      | path             | GET | POST | PUT | DELETE |
+      | /records/1/extra | -   | -    | -   | -      |
+      | /RECORDS         | -   | -    | -   | -      |
+      | /                | -   | -    | -   | -      |
+      | /nothing         | -   | -    | -   | -      |
+
+  Scenario: The full matrix
+    Then the routing matrix should match the reference
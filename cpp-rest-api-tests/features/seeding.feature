@@ -0,0 +1,28 @@
+Feature: Seeded contact data
+  Contacts generated from a seed are identical on every run, and the load
+  manifest records which ID each one was given. A manifest written by
+  cmd/loadcontacts can be checked with "Given the manifest "<path>"".
+
+  Background:
+    Given the API is running
+    And the database should be empty
+
+  Scenario: Load the load_contacts.sh equivalent
+    When I load 100 generated contacts with seed 42
+    Then the manifest should list 100 created contacts
+    And every contact in the manifest should exist
+    When I send a GET request to "/records?phone=555"
+    Then the response should contain 100 contacts
+
+  Scenario: Load localized contacts
+    When I load 25 generated "de_DE" contacts with seed 7
+    Then the manifest should list 25 created contacts
+    And every contact in the manifest should exist
+
+  Scenario: Check a manifest file written by a load
+    When I load 20 generated contacts with seed 3
+    And I write the manifest to "seeding.manifest.json"
+    Given the manifest "seeding.manifest.json"
+    Then the manifest should list 20 created contacts
+    And the manifest IDs should run from 1 to 20
+    And every contact in the manifest should exist
@@ -0,0 +1,116 @@
+Feature: Update semantics
+  PUT /records/{id} merges the body into the stored contact, which starts
+  every scenario with every field set:
+
+    | first_name | middle_name | last_name | street             | city   | state | zip  | phone      | email           |
+    | Ada        | King        | Lovelace  | 12 St James Square | London | LDN   | SW1Y | 5551234567 | ada@example.com |
+
+  Fields are assigned in this column order; a field that is not a string
+  stops the update with 400, after the fields before it were assigned.
+
+  Background:
+    Given the API is running
+    And a contact with every field set exists
+
+  Scenario: Fields left out keep their value
+    When I update it with PUT:
+      """
+      {"city": "Paris", "zip": "75001"}
+      """
+    Then the update status should be 200
+    And the stored contact should differ only in:
+      | field | value |
+      | city  | Paris |
+      | zip   | 75001 |
+
+  Scenario: An empty string clears a field
+    When I update it with PUT:
+      """
+      {"middle_name": ""}
+      """
+    Then the update status should be 200
+    And the stored contact should differ only in:
+      | field       | value |
+      | middle_name |       |
+
+  Scenario: null is a type error, not a way to clear a field
+    When I update it with PUT:
+      """
+      {"phone": null}
+      """
+    Then the update status should be 400
+    And the stored contact should be unchanged
+
+  Scenario: A type error keeps the fields assigned before it
+    When I update it with PUT:
+      """
+      {"email": "grace@example.com", "phone": 5551234567, "first_name": "Grace"}
+      """
+    Then the update status should be 400
+    And the stored contact should differ only in:
+      | field      | value |
+      | first_name | Grace |
+
+  Scenario: Unknown fields are ignored
+    When I update it with PUT:
+      """
+      {"nickname": "Countess", "First_Name": "Grace"}
+      """
+    Then the update status should be 200
+    And the stored contact should be unchanged
+
+  Scenario: The ID cannot be changed
+    When I update it with PUT:
+      """
+      {"id": 99, "city": "Paris"}
+      """
+    Then the update status should be 200
+    And the contact should keep its ID
+    And the stored contact should differ only in:
+      | field | value |
+      | city  | Paris |
+
+  Scenario: A body that is not an object changes nothing
+    When I update it with PUT:
+      """
+      ["Grace"]
+      """
+    Then the update status should be 400
+    And the stored contact should be unchanged
+
+  Scenario Outline: Every <rule> case
+    Then every "<rule>" PUT update case should hold
+
+    Examples:
+      | rule    |
+      | merge   |
+      | clear   |
+      | type    |
+      | unknown |
+      | id      |
+      | body    |
+
+  Scenario: null clears a field with JSON Merge Patch
+    Given the API supports JSON Merge Patch
+    When I update it with PATCH:
+      """
+      {"middle_name": null, "city": "Paris"}
+      """
+    Then the update status should be 200
+    And the stored contact should differ only in:
+      | field       | value |
+      | middle_name |       |
+      | city        | Paris |
+
+  Scenario: A merge patch with a type error changes nothing
+    Given the API supports JSON Merge Patch
+    When I update it with PATCH:
+      """
+      {"first_name": "Grace", "phone": true}
+      """
+    Then the update status should be 400
+    And the stored contact should be unchanged
+
+  Scenario: Every merge patch case
+    Given the API supports JSON Merge Patch
+    Then every merge patch case should hold
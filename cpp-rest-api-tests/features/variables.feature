@@ -0,0 +1,57 @@
+Feature: Scenario variables
+  Background:
+    Given the API is running
+    And the database should be empty
+
+  Scenario: Refer to several contacts by name
+    When I send a POST request to "/records" with contact details:
+      """
+      {"first_name": "Alice", "last_name": "Smith", "phone": "4155550100"}
+      """
+    And I save the response field "id" as "alice"
+    And I send a POST request to "/records" with contact details:
+      """
+      {"first_name": "Bob", "last_name": "Jones", "phone": "2125550199"}
+      """
+    And I save the response field "id" as "bob"
+    When I send a PUT request to "/records/{alice}" with updated details:
+      """
+      {"city": "Oakland"}
+      """
+    Then the response field "id" should equal "{alice}"
+    When I send a DELETE request to "/records/{bob}"
+    Then the response status code should be 204
+    And a subsequent GET request to "/records/{bob}" should return 404
+    And a subsequent GET request to "/records/{alice}" should return 200
+    When I send a GET request to "/records"
+    Then the response should match the table:
+      | id      | first_name | city    |
+      | {alice} | Alice      | Oakland |
+
+  Scenario: Generated values
+    Given I set the variable "email" to "{random.email}"
+    And I set the variable "phone" to "{random.phone:503}"
+    And I set the variable "name" to "{random.name:Carol}"
+    When I send a POST request to "/records" with contact details:
+      """
+      {"first_name": "{name}", "phone": "{phone}", "email": "{email}"}
+      """
+    Then the response status code should be 201
+    And the response field "first_name" should equal "{name}"
+    And the response field "email" should equal "{email}"
+    When I send a GET request to "/records?phone=503"
+    Then the response should contain 1 contact
+    And the response field "[0].phone" should equal "{phone}"
+    When I send a GET request to "/records?email={email}"
+    Then the response field "[0].id" should equal "{lastCreatedID}"
+
+  Scenario: Saved values can build new ones
+    Given I have created a contact with phone "7025550123"
+    And I send a GET request to "/records/{lastCreatedID}"
+    And I save the response field "first_name" as "first"
+    When I set the variable "note" to "{first} #{lastCreatedID}"
+    And I send a PUT request to "/records/{lastCreatedID}" with updated details:
+      """
+      {"street": "{note}"}
+      """
+    Then the response field "street" should equal "John #1"
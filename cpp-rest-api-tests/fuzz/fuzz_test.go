@@ -0,0 +1,159 @@
+// Package fuzz holds go test -fuzz targets that throw mutated bodies, path
+// IDs and query strings at a managed API instance. A 5xx, a dropped
+// connection, a timeout or a server that stops answering afterwards all
+// fail the input, which go test then saves under testdata/fuzz. The one
+// exception is the 500 main.cpp sends for an ID that is not an int.
+//
+//	go test ./fuzz -fuzz=FuzzCreateBody -fuzztime=60s
+package fuzz
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/refapi"
+	"cpp-rest-api-tests/testenv"
+)
+
+var targetFlags = config.BindFlags(flag.CommandLine)
+
+var (
+	api   *client.Client
+	calls atomic.Int64
+)
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { api = env.API })
+}
+
+// maybeReset keeps the store from growing without bound over long fuzz
+// runs. It is called first thing in each input, never between setup and
+// the request under test.
+func maybeReset(t *testing.T) {
+	if calls.Add(1)%500 == 0 {
+		if err := api.Reset(context.Background()); err != nil {
+			t.Fatalf("periodic reset failed: %v", err)
+		}
+	}
+}
+
+// exchange sends one request and fails on anything that is not a normal
+// answer from a live server.
+func exchange(t *testing.T, method, path string, body []byte) *client.Response {
+	t.Helper()
+	if !validTarget(path) {
+		t.Skip("not a valid request target")
+	}
+
+	resp, err := api.Do(context.Background(), method, path, body)
+	if err != nil {
+		t.Fatalf("%s %s with body %q: %v", method, path, body, err)
+	}
+	if resp.StatusCode >= 500 && !badID(path, resp) {
+		t.Fatalf("%s %s with body %q: status %d: %s", method, path, body, resp.StatusCode, resp.Body)
+	}
+	if _, err := api.List(context.Background()); err != nil {
+		t.Fatalf("server stopped answering after %s %s with body %q: %v", method, path, body, err)
+	}
+	return resp
+}
+
+// badID reports the known 500: as<int>() throws on a /records/{id} segment
+// that does not start with an int, and the router answers with the
+// exception text.
+func badID(path string, resp *client.Response) bool {
+	return strings.HasPrefix(path, "/records/") && resp.StatusCode == 500 && string(resp.Body) == refapi.MsgBadCast
+}
+
+func FuzzCreateBody(f *testing.F) {
+	f.Add(`{"first_name":"John","last_name":"Doe","phone":"1234567890"}`)
+	f.Add(`{"first_name":5}`)
+	f.Add(`{"first_name":null}`)
+	f.Add(`{"first_name":{"nested":true}}`)
+	f.Add(`[]`)
+	f.Add(`"just a string"`)
+	f.Add(`{"first_name":"\u0000\ud800"}`)
+	f.Add(`{"id":-1,"first_name":"` + strings.Repeat("x", 4096) + `"}`)
+	f.Add(``)
+	f.Fuzz(func(t *testing.T, body string) {
+		maybeReset(t)
+		resp := exchange(t, "POST", "/records", []byte(body))
+		if resp.StatusCode != 201 && resp.StatusCode != 400 {
+			t.Fatalf("POST /records with %q: unexpected status %d", body, resp.StatusCode)
+		}
+	})
+}
+
+func FuzzUpdateBody(f *testing.F) {
+	f.Add(`{"first_name":"Jane"}`)
+	f.Add(`{"phone":123}`)
+	f.Add(`{"email":true}`)
+	f.Add(`{"id":42}`)
+	f.Add(`{`)
+	f.Fuzz(func(t *testing.T, body string) {
+		maybeReset(t)
+		created, err := api.Create(context.Background(), client.Contact{FirstName: "John"})
+		if err != nil {
+			t.Fatalf("failed to create contact: %v", err)
+		}
+		resp := exchange(t, "PUT", fmt.Sprintf("/records/%d", created.ID), []byte(body))
+		if resp.StatusCode != 200 && resp.StatusCode != 400 {
+			t.Fatalf("PUT with %q: unexpected status %d", body, resp.StatusCode)
+		}
+	})
+}
+
+func FuzzRecordID(f *testing.F) {
+	for _, id := range []string{"1", "0", "-1", "abc", "1abc", " 7", "+3", "2147483647", "2147483648", "-2147483649", "99999999999999999999", "1e3", "0x10", "%00"} {
+		f.Add(uint8(0), id)
+	}
+	f.Fuzz(func(t *testing.T, op uint8, id string) {
+		maybeReset(t)
+		path := "/records/" + id
+		switch op % 3 {
+		case 0:
+			exchange(t, "GET", path, nil)
+		case 1:
+			exchange(t, "PUT", path, []byte(`{"first_name":"Fuzz"}`))
+		case 2:
+			exchange(t, "DELETE", path, nil)
+		}
+	})
+}
+
+func FuzzQuery(f *testing.F) {
+	f.Add("first_name=John&phone=123")
+	f.Add("phone=12")
+	f.Add("phone=")
+	f.Add("id=abc")
+	f.Add("city=&city=Springfield")
+	f.Add("%zz=1")
+	f.Add("nickname=x&&&=")
+	f.Fuzz(func(t *testing.T, query string) {
+		maybeReset(t)
+		resp := exchange(t, "GET", "/records?"+query, nil)
+		if resp.StatusCode != 200 {
+			t.Fatalf("GET /records?%s: unexpected status %d", query, resp.StatusCode)
+		}
+	})
+}
+
+// validTarget rejects request targets that cannot go on the wire as-is:
+// spaces, control characters, raw non-ASCII and fragments would only test
+// Go's HTTP client, not the API.
+func validTarget(path string) bool {
+	for i := 0; i < len(path); i++ {
+		if c := path[i]; c <= ' ' || c >= 0x7f || c == '#' {
+			return false
+		}
+	}
+	_, err := url.ParseRequestURI(path)
+	return err == nil
+}
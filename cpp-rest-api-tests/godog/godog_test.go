@@ -1,24 +1,58 @@
 package godog
 
 import (
+	"flag"
 	"testing"
+	"time"
 
 	"github.com/cucumber/godog"
+	"cpp-rest-api-tests/apiserver"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/reporter"
 	"cpp-rest-api-tests/step_definitions"
+	"cpp-rest-api-tests/testenv"
 )
 
+var targetFlags = config.BindFlags(flag.CommandLine)
+
+var tags = flag.String("godog.tags", step_definitions.DefaultTags, "tag expression selecting the scenarios to run, e.g. @stress")
+
+var (
+	cfg    config.Config
+	server *apiserver.Server
+)
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { cfg, server = env.Config, env.Server })
+}
+
 func TestContactFeatures(t *testing.T) {
+	steps := step_definitions.ScenarioInitializer(cfg, server)
 	suite := godog.TestSuite{
 		ScenarioInitializer: func(ctx *godog.ScenarioContext) {
-			step_definitions.InitializeScenario(ctx)
+			server.InstallHooks(ctx)
+			steps(ctx)
 		},
 		Options: &godog.Options{
 			Format:   "progress,cucumber:report.json",
-			Paths:    []string{"../features/contacts.feature"},
+			Paths:    []string{"../features"},
+			Strict:   true,
+			Tags:     *tags,
+			// The isolation hooks reset the shared store, so scenarios
+			// must run one at a time.
+			Concurrency: 1,
 		},
 	}
 
-	if suite.Run() != 0 {
+	status := suite.Run()
+	if _, err := reporter.Generate("report.json", reporter.Files{
+		JUnit:   "junit.xml",
+		HTML:    "report.html",
+		History: "history.json",
+	}, time.Now()); err != nil {
+		t.Errorf("failed to write reports: %v", err)
+	}
+	if status != 0 {
 		t.Fatal("failed to run contact feature tests")
 	}
 }
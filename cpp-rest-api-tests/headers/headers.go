@@ -0,0 +1,293 @@
+// Package headers probes every operation in the OpenAPI document with
+// header variations and grades the answers into a conformance matrix.
+//
+// Each check is either a MUST, whose failure is an error (a wrong
+// Content-Length, a 405 without Allow, missing CORS headers when CORS is
+// expected), or a SHOULD, whose failure is recorded as a gap (no
+// Content-Type, request Content-Type and Accept ignored, 404 where 405
+// belongs). main.cpp has several known gaps and no failures.
+package headers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/openapi"
+)
+
+// Check names, in matrix column order.
+const (
+	CheckContentType   = "content-type"
+	CheckLength        = "content-length"
+	CheckWrongType     = "request-type-wrong"
+	CheckMissingType   = "request-type-missing"
+	CheckAcceptJSON    = "accept-json"
+	CheckAcceptOther   = "accept-unsupported"
+	CheckAllow         = "allow-on-405"
+	CheckCORS          = "cors"
+	CheckCORSPreflight = "cors-preflight"
+)
+
+var Checks = []string{
+	CheckContentType, CheckLength, CheckWrongType, CheckMissingType,
+	CheckAcceptJSON, CheckAcceptOther, CheckAllow, CheckCORS, CheckCORSPreflight,
+}
+
+// Result statuses.
+const (
+	Pass = "pass"
+	Gap  = "gap"  // a SHOULD is not met
+	Fail = "fail" // a MUST is not met
+	NA   = "n/a"
+)
+
+type Options struct {
+	// CORS makes the CORS checks MUSTs. Without it they only note
+	// whether the server sends CORS headers.
+	CORS   bool
+	Origin string
+}
+
+// sampleBody is what the probes send to operations that take a body.
+const sampleBody = `{"first_name":"Probe","last_name":"Headers","phone":"5550001111"}`
+
+type prober struct {
+	api  *client.Client
+	opts Options
+}
+
+// Run resets the store, probes every operation and resets it again.
+func Run(ctx context.Context, api *client.Client, opts Options) (*Matrix, error) {
+	if opts.Origin == "" {
+		opts.Origin = "https://example.com"
+	}
+	p := &prober{api: api, opts: opts}
+	if err := api.Reset(ctx); err != nil {
+		return nil, err
+	}
+	m := &Matrix{Checks: Checks, CORS: opts.CORS}
+	for _, op := range openapi.MustLoad().Operations {
+		row, err := p.probe(ctx, op)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", op, err)
+		}
+		m.Rows = append(m.Rows, row)
+	}
+	if err := api.Reset(ctx); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// send makes one request to op, creating a contact first when the path
+// needs an ID, so that every variation sees the same state.
+func (p *prober) send(ctx context.Context, op *openapi.Operation, headers map[string]string) (*client.Response, error) {
+	return p.sendMethod(ctx, op.Method, op, headers)
+}
+
+func (p *prober) sendMethod(ctx context.Context, method string, op *openapi.Operation, headers map[string]string) (*client.Response, error) {
+	path := op.Path
+	if strings.Contains(path, "{id}") {
+		created, err := p.api.Create(ctx, client.Contact{FirstName: "Probe", Phone: "5550001111"})
+		if err != nil {
+			return nil, err
+		}
+		path = strings.ReplaceAll(path, "{id}", strconv.Itoa(created.ID))
+	}
+	var body []byte
+	if op.RequestBody != nil && method == op.Method {
+		body = []byte(sampleBody)
+	}
+	return p.api.DoWithHeaders(ctx, method, path, headers, body)
+}
+
+func (p *prober) probe(ctx context.Context, op *openapi.Operation) (Row, error) {
+	row := Row{Operation: op.ID, Method: op.Method, Path: op.Path, Results: map[string]Result{}}
+	set := func(check string, r Result) { row.Results[check] = r }
+
+	base, err := p.send(ctx, op, nil)
+	if err != nil {
+		return row, err
+	}
+	set(CheckContentType, contentType(base))
+	set(CheckLength, contentLength(base))
+
+	if op.RequestBody != nil {
+		wrong, err := p.send(ctx, op, map[string]string{"Content-Type": "text/plain"})
+		if err != nil {
+			return row, err
+		}
+		set(CheckWrongType, wrongType(wrong))
+		missing, err := p.send(ctx, op, map[string]string{"Content-Type": ""})
+		if err != nil {
+			return row, err
+		}
+		set(CheckMissingType, missingType(base, missing))
+	} else {
+		set(CheckWrongType, Result{Status: NA, Detail: "no request body"})
+		set(CheckMissingType, Result{Status: NA, Detail: "no request body"})
+	}
+
+	acceptJSON, err := p.send(ctx, op, map[string]string{"Accept": "application/json"})
+	if err != nil {
+		return row, err
+	}
+	set(CheckAcceptJSON, sameAnswer(base, acceptJSON, Fail))
+	acceptHTML, err := p.send(ctx, op, map[string]string{"Accept": "text/html"})
+	if err != nil {
+		return row, err
+	}
+	set(CheckAcceptOther, notAcceptable(base, acceptHTML))
+
+	unbound, err := p.sendMethod(ctx, "PATCH", op, nil)
+	if err != nil {
+		return row, err
+	}
+	set(CheckAllow, allow(unbound))
+
+	withOrigin, err := p.send(ctx, op, map[string]string{"Origin": p.opts.Origin})
+	if err != nil {
+		return row, err
+	}
+	set(CheckCORS, p.cors(withOrigin))
+	preflight, err := p.sendMethod(ctx, "OPTIONS", op, map[string]string{
+		"Origin":                        p.opts.Origin,
+		"Access-Control-Request-Method": op.Method,
+	})
+	if err != nil {
+		return row, err
+	}
+	set(CheckCORSPreflight, p.preflight(op.Method, preflight))
+	return row, nil
+}
+
+func contentType(resp *client.Response) Result {
+	if len(resp.Body) == 0 {
+		return Result{Status: NA, Detail: fmt.Sprintf("%d has no body", resp.StatusCode)}
+	}
+	want := "text/plain"
+	if json.Valid(resp.Body) {
+		want = "application/json"
+	}
+	header := resp.Header.Get("Content-Type")
+	if header == "" {
+		return Result{Status: Gap, Detail: "missing, body is " + want}
+	}
+	mt, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return Result{Status: Fail, Detail: fmt.Sprintf("malformed %q", header)}
+	}
+	if mt != want {
+		return Result{Status: Fail, Detail: fmt.Sprintf("%s, body is %s", mt, want)}
+	}
+	return Result{Status: Pass, Detail: header}
+}
+
+func contentLength(resp *client.Response) Result {
+	header := resp.Header.Get("Content-Length")
+	if header == "" {
+		if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+			return Result{Status: Pass, Detail: "none on " + strconv.Itoa(resp.StatusCode)}
+		}
+		// net/http hides Transfer-Encoding; a missing length on a body
+		// that arrived intact means it was chunked.
+		return Result{Status: Pass, Detail: "chunked"}
+	}
+	n, err := strconv.Atoi(header)
+	if err != nil {
+		return Result{Status: Fail, Detail: fmt.Sprintf("malformed %q", header)}
+	}
+	if resp.StatusCode == http.StatusNoContent && n != 0 {
+		return Result{Status: Fail, Detail: fmt.Sprintf("%d on 204", n)}
+	}
+	if n != len(resp.Body) {
+		return Result{Status: Fail, Detail: fmt.Sprintf("says %d, body has %d bytes", n, len(resp.Body))}
+	}
+	return Result{Status: Pass, Detail: header}
+}
+
+func wrongType(resp *client.Response) Result {
+	if resp.StatusCode == http.StatusUnsupportedMediaType {
+		return Result{Status: Pass, Detail: "415"}
+	}
+	return Result{Status: Gap, Detail: fmt.Sprintf("text/plain answered %d, want 415", resp.StatusCode)}
+}
+
+func missingType(base, resp *client.Response) Result {
+	switch resp.StatusCode {
+	case http.StatusUnsupportedMediaType:
+		return Result{Status: Pass, Detail: "415"}
+	case base.StatusCode:
+		return Result{Status: Pass, Detail: "treated as JSON"}
+	}
+	return Result{Status: Fail, Detail: fmt.Sprintf("answered %d, JSON got %d", resp.StatusCode, base.StatusCode)}
+}
+
+// sameAnswer compares status codes only; bodies differ in the IDs the
+// fresh contacts got.
+func sameAnswer(base, resp *client.Response, status string) Result {
+	if resp.StatusCode != base.StatusCode {
+		return Result{Status: status, Detail: fmt.Sprintf("answered %d, without Accept %d", resp.StatusCode, base.StatusCode)}
+	}
+	return Result{Status: Pass, Detail: strconv.Itoa(resp.StatusCode)}
+}
+
+func notAcceptable(base, resp *client.Response) Result {
+	if len(base.Body) == 0 {
+		return sameAnswer(base, resp, Fail)
+	}
+	if resp.StatusCode == http.StatusNotAcceptable {
+		return Result{Status: Pass, Detail: "406"}
+	}
+	return Result{Status: Gap, Detail: fmt.Sprintf("text/html answered %d, want 406", resp.StatusCode)}
+}
+
+func allow(resp *client.Response) Result {
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		return Result{Status: Gap, Detail: fmt.Sprintf("PATCH answered %d, want 405", resp.StatusCode)}
+	}
+	if resp.Header.Get("Allow") == "" {
+		return Result{Status: Fail, Detail: "405 without Allow"}
+	}
+	return Result{Status: Pass, Detail: "Allow: " + resp.Header.Get("Allow")}
+}
+
+func (p *prober) cors(resp *client.Response) Result {
+	origin := resp.Header.Get("Access-Control-Allow-Origin")
+	switch {
+	case !p.opts.CORS && origin == "":
+		return Result{Status: NA, Detail: "disabled"}
+	case !p.opts.CORS:
+		return Result{Status: NA, Detail: "Access-Control-Allow-Origin: " + origin}
+	case origin == "*" || origin == p.opts.Origin:
+		return Result{Status: Pass, Detail: origin}
+	case origin == "":
+		return Result{Status: Fail, Detail: "no Access-Control-Allow-Origin"}
+	}
+	return Result{Status: Fail, Detail: fmt.Sprintf("Access-Control-Allow-Origin %q, want %q", origin, p.opts.Origin)}
+}
+
+func (p *prober) preflight(method string, resp *client.Response) Result {
+	methods := resp.Header.Get("Access-Control-Allow-Methods")
+	if !p.opts.CORS {
+		if methods == "" {
+			return Result{Status: NA, Detail: fmt.Sprintf("disabled (OPTIONS answered %d)", resp.StatusCode)}
+		}
+		return Result{Status: NA, Detail: "Access-Control-Allow-Methods: " + methods}
+	}
+	if resp.StatusCode/100 != 2 {
+		return Result{Status: Fail, Detail: fmt.Sprintf("OPTIONS answered %d", resp.StatusCode)}
+	}
+	for _, m := range strings.Split(methods, ",") {
+		if strings.EqualFold(strings.TrimSpace(m), method) {
+			return Result{Status: Pass, Detail: methods}
+		}
+	}
+	return Result{Status: Fail, Detail: fmt.Sprintf("Access-Control-Allow-Methods %q lacks %s", methods, method)}
+}
@@ -0,0 +1,122 @@
+package headers
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/refapi"
+	"cpp-rest-api-tests/testenv"
+)
+
+var (
+	targetFlags = config.BindFlags(flag.CommandLine)
+	cors        = flag.Bool("headers.cors", false, "expect CORS headers")
+	reportPath  = flag.String("headers.report", "", "write the conformance matrix as JSON to this file")
+)
+
+var api *client.Client
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { api = env.API })
+}
+
+func TestConformance(t *testing.T) {
+	m, err := Run(context.Background(), api, Options{CORS: *cors})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var table bytes.Buffer
+	m.WriteTable(&table)
+	t.Logf("header conformance:\n%s", table.String())
+	if *reportPath != "" {
+		f, err := os.Create(*reportPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := m.WriteJSON(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The known gaps of main.cpp, pinned so that fixing one shows up here.
+func TestReferenceGaps(t *testing.T) {
+	srv, _ := refapi.NewServer()
+	defer srv.Close()
+	m, err := Run(context.Background(), client.New(srv.URL, srv.Client()), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ operation, check, status string }{
+		{"getRecord", CheckContentType, Gap},
+		{"getRecord", CheckLength, Pass},
+		{"deleteRecord", CheckContentType, NA},
+		{"createRecord", CheckWrongType, Gap},
+		{"createRecord", CheckMissingType, Pass},
+		{"queryRecords", CheckWrongType, NA},
+		{"queryRecords", CheckAcceptJSON, Pass},
+		{"queryRecords", CheckAcceptOther, Gap},
+		{"resetRecords", CheckAcceptOther, Pass},
+		{"updateRecord", CheckAllow, Gap},
+		{"updateRecord", CheckCORS, NA},
+	}
+	for _, tt := range tests {
+		if r, _ := m.Get(tt.operation, tt.check); r.Status != tt.status {
+			t.Errorf("%s %s = %s (%s), want %s", tt.operation, tt.check, r.Status, r.Detail, tt.status)
+		}
+	}
+	if err := m.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A server that does answer 405 and claims CORS support must get both
+// right; this one forgets Allow and only half implements CORS.
+func TestFailuresAreReported(t *testing.T) {
+	ref := refapi.NewHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PATCH":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case "OPTIONS":
+			w.Header().Set("Access-Control-Allow-Methods", "GET")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			ref.ServeHTTP(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	m, err := Run(context.Background(), client.New(srv.URL, srv.Client()), Options{CORS: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Err()
+	if err == nil {
+		t.Fatal("expected failures")
+	}
+	for _, want := range []string{
+		"getRecord allow-on-405: 405 without Allow",
+		`createRecord cors-preflight: Access-Control-Allow-Methods "GET" lacks POST`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("%v\ndoes not mention %q", err, want)
+		}
+	}
+	if r, _ := m.Get("getRecord", CheckCORSPreflight); r.Status != Pass {
+		t.Fatalf("getRecord preflight = %+v", r)
+	}
+}
@@ -0,0 +1,93 @@
+package headers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+type Result struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Row holds the results for one operation.
+type Row struct {
+	Operation string            `json:"operation"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Results   map[string]Result `json:"results"`
+}
+
+// Matrix is the conformance matrix: one row per operation, one column per
+// check.
+type Matrix struct {
+	Checks []string `json:"checks"`
+	CORS   bool     `json:"cors"`
+	Rows   []Row    `json:"rows"`
+}
+
+// Get returns the result of check for the operation with the given ID.
+func (m *Matrix) Get(operation, check string) (Result, bool) {
+	for _, row := range m.Rows {
+		if row.Operation == operation {
+			r, ok := row.Results[check]
+			return r, ok
+		}
+	}
+	return Result{}, false
+}
+
+// With lists "operation check: detail" for every result with status.
+func (m *Matrix) With(status string) []string {
+	var out []string
+	for _, row := range m.Rows {
+		for _, check := range m.Checks {
+			if r := row.Results[check]; r.Status == status {
+				out = append(out, fmt.Sprintf("%s %s: %s", row.Operation, check, r.Detail))
+			}
+		}
+	}
+	return out
+}
+
+// Err reports every failed MUST. Gaps are not errors.
+func (m *Matrix) Err() error {
+	failures := m.With(Fail)
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d header conformance failure(s):\n  %s", len(failures), strings.Join(failures, "\n  "))
+}
+
+// WriteJSON writes the matrix as indented JSON.
+func (m *Matrix) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// WriteTable writes the statuses as an aligned table followed by the
+// details of every result that is not a pass.
+func (m *Matrix) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "operation\t%s\t\n", strings.Join(m.Checks, "\t"))
+	for _, row := range m.Rows {
+		cells := make([]string, len(m.Checks))
+		for i, check := range m.Checks {
+			cells[i] = row.Results[check].Status
+		}
+		fmt.Fprintf(tw, "%s\t%s\t\n", row.Operation, strings.Join(cells, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, status := range []string{Fail, Gap} {
+		for _, line := range m.With(status) {
+			fmt.Fprintf(w, "%s  %s\n", status, line)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package headers
+
+import (
+	"context"
+	"fmt"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/scenario"
+)
+
+// Steps exposes the conformance matrix to godog scenarios.
+type Steps struct {
+	api    *client.Client
+	matrix *Matrix
+}
+
+func NewSteps(api *client.Client) *Steps {
+	return &Steps{api: api}
+}
+
+func (s *Steps) Register(ctx scenario.Context) {
+	ctx.Step(`^I run the header conformance checks$`, s.iRunTheHeaderConformanceChecks)
+	ctx.Step(`^I run the header conformance checks expecting CORS$`, s.iRunTheHeaderConformanceChecksExpectingCORS)
+	ctx.Step(`^the header conformance matrix should have no failures$`, s.theMatrixShouldHaveNoFailures)
+	ctx.Step(`^the header check "([^"]*)" should be "(pass|gap|fail|n/a)" for "([^"]*)"$`, s.theHeaderCheckShouldBeFor)
+	ctx.Step(`^the header check "([^"]*)" should be "(pass|gap|fail|n/a)" for every operation$`, s.theHeaderCheckShouldBeForEveryOperation)
+}
+
+func (s *Steps) run(opts Options) error {
+	m, err := Run(context.Background(), s.api, opts)
+	if err != nil {
+		return err
+	}
+	s.matrix = m
+	return nil
+}
+
+func (s *Steps) iRunTheHeaderConformanceChecks() error {
+	return s.run(Options{})
+}
+
+func (s *Steps) iRunTheHeaderConformanceChecksExpectingCORS() error {
+	return s.run(Options{CORS: true})
+}
+
+func (s *Steps) theMatrixShouldHaveNoFailures() error {
+	if s.matrix == nil {
+		return fmt.Errorf("the header conformance checks have not run")
+	}
+	return s.matrix.Err()
+}
+
+func (s *Steps) theHeaderCheckShouldBeFor(check, status, operation string) error {
+	if s.matrix == nil {
+		return fmt.Errorf("the header conformance checks have not run")
+	}
+	r, ok := s.matrix.Get(operation, check)
+	if !ok {
+		return fmt.Errorf("no result for check %q on %q", check, operation)
+	}
+	if r.Status != status {
+		return fmt.Errorf("%s %s: expected %s, got %s (%s)", operation, check, status, r.Status, r.Detail)
+	}
+	return nil
+}
+
+func (s *Steps) theHeaderCheckShouldBeForEveryOperation(check, status string) error {
+	if s.matrix == nil {
+		return fmt.Errorf("the header conformance checks have not run")
+	}
+	for _, row := range s.matrix.Rows {
+		if err := s.theHeaderCheckShouldBeFor(check, status, row.Operation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
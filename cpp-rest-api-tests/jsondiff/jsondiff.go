@@ -0,0 +1,49 @@
+// Package jsondiff renders mismatches between JSON documents as
+// diffmatchpatch pretty text, the format the step definitions report
+// response mismatches in.
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Compare marshals both values and returns an error carrying a
+// (-expected +actual) diff when they differ.
+func Compare(actual, expected interface{}) error {
+	actualJSON, err := json.Marshal(actual)
+	if err != nil {
+		return fmt.Errorf("failed to marshal actual: %v", err)
+	}
+	expectedJSON, err := json.Marshal(expected)
+	if err != nil {
+		return fmt.Errorf("failed to marshal expected: %v", err)
+	}
+	if string(actualJSON) != string(expectedJSON) {
+		return fmt.Errorf("response mismatch (-expected +actual):\n%s", Diff(string(expectedJSON), string(actualJSON)))
+	}
+	return nil
+}
+
+// Diff returns the pretty-printed character diff turning a into b.
+func Diff(a, b string) string {
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMain(a, b, false)
+	return dmp.DiffPrettyText(diffs)
+}
+
+// Canonical re-encodes a JSON document so that formatting and key order do
+// not count as differences. ok is false when data is not JSON.
+func Canonical(data []byte) (string, bool) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", false
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(out), true
+}
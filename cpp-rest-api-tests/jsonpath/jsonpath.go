@@ -0,0 +1,133 @@
+// Package jsonpath addresses values inside decoded JSON documents with the
+// subset of JSONPath the step definitions need: an optional leading "$",
+// dotted member names and [n] array indexes. Negative indexes count from
+// the end, so "$[-1].id" is the ID of the last record in a listing.
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type segment struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+func (s segment) String() string {
+	if s.isIdx {
+		return fmt.Sprintf("[%d]", s.index)
+	}
+	return "." + s.key
+}
+
+// Get returns the value at path in doc, which must be the result of
+// decoding JSON into an interface{}.
+func Get(doc interface{}, path string) (interface{}, error) {
+	segs, err := parse(path)
+	if err != nil {
+		return nil, err
+	}
+	cur := doc
+	at := "$"
+	for _, seg := range segs {
+		if seg.isIdx {
+			arr, ok := cur.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("jsonpath %q: %s is %s, not an array", path, at, kind(cur))
+			}
+			i := seg.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, fmt.Errorf("jsonpath %q: index %d out of range, %s has %d elements", path, seg.index, at, len(arr))
+			}
+			cur = arr[i]
+		} else {
+			obj, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("jsonpath %q: %s is %s, not an object", path, at, kind(cur))
+			}
+			v, ok := obj[seg.key]
+			if !ok {
+				return nil, fmt.Errorf("jsonpath %q: %s has no member %q", path, at, seg.key)
+			}
+			cur = v
+		}
+		at += seg.String()
+	}
+	return cur, nil
+}
+
+// Text renders a value for comparison with a plain string from a feature
+// file: strings as themselves, everything else as compact JSON.
+func Text(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(out)
+}
+
+func parse(path string) ([]segment, error) {
+	p := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var segs []segment
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			n := strings.IndexAny(p, ".[")
+			if n < 0 {
+				n = len(p)
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("jsonpath %q: empty member name", path)
+			}
+			segs = append(segs, segment{key: p[:n]})
+			p = p[n:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath %q: unclosed [", path)
+			}
+			i, err := strconv.Atoi(strings.TrimSpace(p[1:end]))
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath %q: bad index %q", path, p[1:end])
+			}
+			segs = append(segs, segment{index: i, isIdx: true})
+			p = p[end+1:]
+		default:
+			// A bare leading name, as in "first_name" or "records[0]".
+			if len(segs) > 0 {
+				return nil, fmt.Errorf("jsonpath %q: expected . or [ before %q", path, p)
+			}
+			p = "." + p
+		}
+	}
+	return segs, nil
+}
+
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	}
+	return fmt.Sprintf("%T", v)
+}
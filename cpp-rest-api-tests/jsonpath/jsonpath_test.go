@@ -0,0 +1,67 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const listing = `[{"id":1,"first_name":"John","tags":["a","b"]},{"id":2,"first_name":"Jane","tags":[]}]`
+
+func TestGet(t *testing.T) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(listing), &doc); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path, want string
+	}{
+		{"$[0].id", "1"},
+		{"[0].first_name", "John"},
+		{"$[1].id", "2"},
+		{"$[-1].first_name", "Jane"},
+		{"[0].tags[1]", "b"},
+		{"$[1].tags", "[]"},
+	}
+	for _, tt := range tests {
+		v, err := Get(doc, tt.path)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.path, err)
+		}
+		if got := Text(v); got != tt.want {
+			t.Fatalf("%q = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	if v, err := Get(doc, "$"); err != nil || len(v.([]interface{})) != 2 {
+		t.Fatalf("root: %v, %v", v, err)
+	}
+
+	var obj interface{}
+	json.Unmarshal([]byte(`{"id":7,"first_name":"Ann"}`), &obj)
+	if v, err := Get(obj, "first_name"); err != nil || v != "Ann" {
+		t.Fatalf("bare name: %v, %v", v, err)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(listing), &doc)
+	tests := []struct {
+		path, want string
+	}{
+		{"first_name", "$ is an array, not an object"},
+		{"[2]", "index 2 out of range, $ has 2 elements"},
+		{"[0].phone", `$[0] has no member "phone"`},
+		{"[0].first_name[0]", "$[0].first_name is a string, not an array"},
+		{"[x]", `bad index "x"`},
+		{"[0", "unclosed ["},
+		{"[0]..id", "empty member name"},
+	}
+	for _, tt := range tests {
+		_, err := Get(doc, tt.path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%q: got %v, want it to mention %q", tt.path, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+// Package loadgen drives a weighted mix of reads, creates and queries at
+// the contacts API and reports latency percentiles, throughput and error
+// rates. Seeding the store with different record counts shows how the
+// linear find_if lookups and full-scan queries in main.cpp degrade.
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cpp-rest-api-tests/client"
+)
+
+// Operation names used in Mix and in reports.
+const (
+	OpRead   = "read"
+	OpCreate = "create"
+	OpQuery  = "query"
+)
+
+// Mix weights the operations; weights need not add up to 100.
+type Mix map[string]int
+
+// ParseMix reads "read=70,create=10,query=20".
+func ParseMix(s string) (Mix, error) {
+	mix := Mix{}
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry %q, expected op=weight", part)
+		}
+		if name != OpRead && name != OpCreate && name != OpQuery {
+			return nil, fmt.Errorf("unknown operation %q in mix (known: read, create, query)", name)
+		}
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight %q for %s", weight, name)
+		}
+		mix[name] = w
+	}
+	return mix, nil
+}
+
+type Options struct {
+	// Records is how many contacts are created before measuring.
+	Records int
+	Mix     Mix
+	// Concurrency is the number of workers. With Rate zero each worker
+	// sends back to back; otherwise requests are paced to Rate per second
+	// across all workers.
+	Concurrency int
+	// Rate is at most MaxRate, one request per nanosecond tick.
+	Rate int
+	// The run stops after Duration or Requests, whichever is set and
+	// comes first.
+	Duration time.Duration
+	Requests int
+	Seed     int64
+}
+
+// MaxRate is the highest Rate a ticker can pace.
+const MaxRate = int(time.Second)
+
+// Stats summarises one operation, or the whole run.
+type Stats struct {
+	Count     int     `json:"count"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	MeanMS    float64 `json:"mean_ms"`
+	P50MS     float64 `json:"p50_ms"`
+	P95MS     float64 `json:"p95_ms"`
+	P99MS     float64 `json:"p99_ms"`
+	MaxMS     float64 `json:"max_ms"`
+}
+
+type Report struct {
+	Records    int              `json:"records"`
+	Duration   float64          `json:"duration_s"`
+	Throughput float64          `json:"throughput_rps"`
+	Total      Stats            `json:"total"`
+	Operations map[string]Stats `json:"operations"`
+}
+
+type sample struct {
+	op      string
+	latency time.Duration
+	failed  bool
+}
+
+// Seed resets the store and creates n contacts spread over a handful of
+// cities, states and area codes so queries have something to match.
+func Seed(ctx context.Context, api *client.Client, n, concurrency int) ([]int, error) {
+	if err := api.Reset(ctx); err != nil {
+		return nil, fmt.Errorf("failed to reset before seeding: %v", err)
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ids := make([]int, 0, n)
+	var mu sync.Mutex
+	var firstErr error
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				created, err := api.Create(ctx, contactFor(i))
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				} else if err == nil {
+					ids = append(ids, created.ID)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return ids, fmt.Errorf("failed to seed contacts: %v", firstErr)
+	}
+	return ids, nil
+}
+
+var (
+	cities    = []string{"Anytown", "Springfield", "Riverside", "Greenville", "Fairview"}
+	states    = []string{"CA", "NY", "TX", "FL", "PA"}
+	areaCodes = []string{"555", "212", "415", "312", "713"}
+)
+
+func contactFor(i int) client.Contact {
+	return client.Contact{
+		FirstName: fmt.Sprintf("Load%d", i),
+		LastName:  "Tester",
+		City:      cities[i%len(cities)],
+		State:     states[i%len(states)],
+		Phone:     fmt.Sprintf("%s%07d", areaCodes[i%len(areaCodes)], i),
+	}
+}
+
+// Run seeds the store and then measures the configured mix.
+func Run(ctx context.Context, api *client.Client, opts Options) (*Report, error) {
+	if opts.Rate < 0 || opts.Rate > MaxRate {
+		return nil, fmt.Errorf("rate %d is out of range (0-%d requests per second)", opts.Rate, MaxRate)
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if len(opts.Mix) == 0 {
+		opts.Mix = Mix{OpRead: 70, OpCreate: 10, OpQuery: 20}
+	}
+	if opts.Duration == 0 && opts.Requests == 0 {
+		opts.Duration = 10 * time.Second
+	}
+
+	ids, err := Seed(ctx, api, opts.Records, opts.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	var pace <-chan time.Time
+	if opts.Rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(opts.Rate))
+		defer ticker.Stop()
+		pace = ticker.C
+	}
+
+	var (
+		mu      sync.Mutex
+		samples []sample
+		issued  int
+		wg      sync.WaitGroup
+	)
+	take := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if opts.Requests > 0 && issued >= opts.Requests {
+			return false
+		}
+		issued++
+		return true
+	}
+
+	start := time.Now()
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(opts.Seed + int64(w)))
+			for ctx.Err() == nil && take() {
+				if pace != nil {
+					select {
+					case <-pace:
+					case <-ctx.Done():
+						return
+					}
+				}
+				s := do(ctx, api, rnd, opts.Mix, ids, opts.Records)
+				if ctx.Err() != nil && s.failed {
+					// Cut off by the deadline, not a server error.
+					return
+				}
+				mu.Lock()
+				samples = append(samples, s)
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	return summarize(samples, elapsed, opts.Records), nil
+}
+
+func do(ctx context.Context, api *client.Client, rnd *rand.Rand, mix Mix, ids []int, records int) sample {
+	op := pick(rnd, mix)
+	start := time.Now()
+	var err error
+	switch op {
+	case OpRead:
+		id := 1
+		if len(ids) > 0 {
+			id = ids[rnd.Intn(len(ids))]
+		}
+		_, err = api.Get(ctx, id)
+	case OpCreate:
+		_, err = api.Create(ctx, contactFor(records+rnd.Intn(1000000)))
+	case OpQuery:
+		params := client.QueryParams{City: cities[rnd.Intn(len(cities))]}
+		if rnd.Intn(2) == 0 {
+			params.Phone = areaCodes[rnd.Intn(len(areaCodes))]
+		}
+		_, err = api.Query(ctx, params)
+	}
+	return sample{op: op, latency: time.Since(start), failed: err != nil}
+}
+
+func pick(rnd *rand.Rand, mix Mix) string {
+	total := 0
+	for _, w := range mix {
+		total += w
+	}
+	if total == 0 {
+		return OpRead
+	}
+	n := rnd.Intn(total)
+	for _, op := range []string{OpRead, OpCreate, OpQuery} {
+		if n < mix[op] {
+			return op
+		}
+		n -= mix[op]
+	}
+	return OpRead
+}
+
+func summarize(samples []sample, elapsed time.Duration, records int) *Report {
+	byOp := map[string][]sample{}
+	for _, s := range samples {
+		byOp[s.op] = append(byOp[s.op], s)
+	}
+	report := &Report{
+		Records:    records,
+		Duration:   elapsed.Seconds(),
+		Total:      stats(samples),
+		Operations: map[string]Stats{},
+	}
+	if elapsed > 0 {
+		report.Throughput = float64(len(samples)) / elapsed.Seconds()
+	}
+	for op, s := range byOp {
+		report.Operations[op] = stats(s)
+	}
+	return report
+}
+
+func stats(samples []sample) Stats {
+	st := Stats{Count: len(samples)}
+	if len(samples) == 0 {
+		return st
+	}
+	latencies := make([]time.Duration, len(samples))
+	var sum time.Duration
+	for i, s := range samples {
+		latencies[i] = s.latency
+		sum += s.latency
+		if s.failed {
+			st.Errors++
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	st.ErrorRate = float64(st.Errors) / float64(len(samples))
+	st.MeanMS = ms(sum / time.Duration(len(samples)))
+	st.P50MS = ms(percentile(latencies, 50))
+	st.P95MS = ms(percentile(latencies, 95))
+	st.P99MS = ms(percentile(latencies, 99))
+	st.MaxMS = ms(latencies[len(latencies)-1])
+	return st
+}
+
+// percentile uses the nearest-rank method on sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
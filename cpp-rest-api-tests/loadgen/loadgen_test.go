@@ -0,0 +1,91 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/testenv"
+)
+
+var targetFlags = config.BindFlags(flag.CommandLine)
+
+var api *client.Client
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { api = env.API })
+}
+
+func TestRunReports(t *testing.T) {
+	report, err := Run(context.Background(), api, Options{Records: 50, Concurrency: 4, Requests: 300, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total.Count != 300 || report.Total.Errors != 0 {
+		t.Fatalf("unexpected totals: %+v", report.Total)
+	}
+	for _, op := range []string{OpRead, OpCreate, OpQuery} {
+		s := report.Operations[op]
+		if s.Count == 0 || s.P50MS > s.P95MS || s.P95MS > s.P99MS || s.P99MS > s.MaxMS {
+			t.Errorf("%s: inconsistent stats %+v", op, s)
+		}
+	}
+
+	var table, js bytes.Buffer
+	report.WriteTable(&table)
+	report.WriteJSON(&js)
+	if !strings.Contains(table.String(), "p99 ms") || !strings.Contains(js.String(), `"p99_ms"`) {
+		t.Fatalf("unexpected output:\n%s\n%s", table.String(), js.String())
+	}
+}
+
+func TestRunRejectsRateOutOfRange(t *testing.T) {
+	for _, rate := range []int{-1, MaxRate + 1} {
+		if _, err := Run(context.Background(), api, Options{Rate: rate, Requests: 1}); err == nil {
+			t.Errorf("Run with rate %d should fail", rate)
+		}
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("read=5, query=1")
+	if err != nil || mix[OpRead] != 5 || mix[OpQuery] != 1 {
+		t.Fatalf("got %v, %v", mix, err)
+	}
+	for _, bad := range []string{"read", "delete=1", "read=-1", "read=x"} {
+		if _, err := ParseMix(bad); err == nil {
+			t.Errorf("ParseMix(%q) should fail", bad)
+		}
+	}
+}
+
+// BenchmarkOperations measures each operation against stores of growing
+// size:
+//
+//	go test ./loadgen -run XXX -bench . -args -contacts.profile=local
+func BenchmarkOperations(b *testing.B) {
+	ctx := context.Background()
+	for _, records := range []int{100, 1000, 5000} {
+		ids, err := Seed(ctx, api, records, 8)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, op := range []string{OpRead, OpCreate, OpQuery} {
+			b.Run(fmt.Sprintf("%s/records=%d", op, records), func(b *testing.B) {
+				rnd := rand.New(rand.NewSource(1))
+				mix := Mix{op: 1}
+				for i := 0; i < b.N; i++ {
+					if s := do(ctx, api, rnd, mix, ids, records); s.failed {
+						b.Fatalf("%s failed", op)
+					}
+				}
+			})
+		}
+	}
+}
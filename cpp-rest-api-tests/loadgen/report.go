@@ -0,0 +1,33 @@
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteTable writes the report as an aligned text table.
+func (r *Report) WriteTable(w io.Writer) error {
+	fmt.Fprintf(w, "records: %d  duration: %.1fs  throughput: %.1f req/s\n\n", r.Records, r.Duration, r.Throughput)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\tcount\terrors\terr%\tmean ms\tp50 ms\tp95 ms\tp99 ms\tmax ms\t")
+	row := func(name string, s Stats) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+			name, s.Count, s.Errors, s.ErrorRate*100, s.MeanMS, s.P50MS, s.P95MS, s.P99MS, s.MaxMS)
+	}
+	for _, op := range []string{OpRead, OpCreate, OpQuery} {
+		if s, ok := r.Operations[op]; ok {
+			row(op, s)
+		}
+	}
+	row("total", r.Total)
+	return tw.Flush()
+}
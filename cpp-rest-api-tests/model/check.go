@@ -0,0 +1,142 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/jsondiff"
+)
+
+// Mismatch reports the first command on which server and model disagreed.
+type Mismatch struct {
+	Step    int
+	Command Command
+	Detail  error
+}
+
+func (m *Mismatch) Error() string {
+	return fmt.Sprintf("step %d (%s): %v", m.Step+1, m.Command, m.Detail)
+}
+
+// Check resets the server, then applies cmds to it and to a fresh model,
+// comparing each answer and the full GET /records listing after every
+// step. It returns a *Mismatch on disagreement.
+func Check(ctx context.Context, api *client.Client, cmds []Command) error {
+	if err := api.Reset(ctx); err != nil {
+		return fmt.Errorf("failed to reset before run: %v", err)
+	}
+	m := New()
+	for i, c := range cmds {
+		want := m.Apply(c)
+		resp, err := api.Do(ctx, c.method(), c.path(), c.body())
+		if err != nil {
+			return &Mismatch{Step: i, Command: c, Detail: err}
+		}
+		if err := compare(want, resp); err != nil {
+			return &Mismatch{Step: i, Command: c, Detail: err}
+		}
+
+		all, err := api.List(ctx)
+		if err != nil {
+			return &Mismatch{Step: i, Command: c, Detail: fmt.Errorf("listing after step: %v", err)}
+		}
+		expected := m.Records
+		if expected == nil {
+			expected = []client.Contact{}
+		}
+		if err := jsondiff.Compare(all, expected); err != nil {
+			return &Mismatch{Step: i, Command: c, Detail: fmt.Errorf("store diverged from model: %v", err)}
+		}
+	}
+	return nil
+}
+
+func compare(want Expected, resp *client.Response) error {
+	if resp.StatusCode != want.Status {
+		return fmt.Errorf("expected status %d, got %d, response body: %s", want.Status, resp.StatusCode, resp.Body)
+	}
+	switch {
+	case want.Record != nil:
+		var got client.Contact
+		if err := json.Unmarshal(resp.Body, &got); err != nil {
+			return fmt.Errorf("invalid JSON: %v, body: %s", err, resp.Body)
+		}
+		return jsondiff.Compare(got, *want.Record)
+	case want.Records != nil:
+		var got []client.Contact
+		if err := json.Unmarshal(resp.Body, &got); err != nil {
+			return fmt.Errorf("invalid JSON: %v, body: %s", err, resp.Body)
+		}
+		return jsondiff.Compare(got, want.Records)
+	case want.Status != http.StatusNoContent && string(resp.Body) != want.Text:
+		return fmt.Errorf("expected body %q, got %q", want.Text, resp.Body)
+	}
+	return nil
+}
+
+// Shrink reduces a failing sequence to a locally minimal one that still
+// fails Check: it drops ever smaller chunks of commands, then drops
+// individual fields and query parameters.
+func Shrink(ctx context.Context, api *client.Client, cmds []Command) []Command {
+	fails := func(candidate []Command) bool {
+		_, ok := Check(ctx, api, candidate).(*Mismatch)
+		return ok
+	}
+
+	// Everything after the failing step is irrelevant.
+	if m, ok := Check(ctx, api, cmds).(*Mismatch); ok {
+		cmds = cmds[:m.Step+1]
+	}
+
+	for chunk := len(cmds) / 2; chunk >= 1; chunk /= 2 {
+		for start := 0; start+chunk <= len(cmds); {
+			candidate := append(append([]Command{}, cmds[:start]...), cmds[start+chunk:]...)
+			if len(candidate) > 0 && fails(candidate) {
+				cmds = candidate
+				continue
+			}
+			start += chunk
+		}
+	}
+
+	for i := range cmds {
+		for _, name := range sortedKeys(cmds[i].Fields) {
+			candidate := cloneCommands(cmds)
+			delete(candidate[i].Fields, name)
+			if fails(candidate) {
+				cmds = candidate
+			}
+		}
+		for _, name := range sortedKeys(cmds[i].Params) {
+			candidate := cloneCommands(cmds)
+			delete(candidate[i].Params, name)
+			if fails(candidate) {
+				cmds = candidate
+			}
+		}
+	}
+	return cmds
+}
+
+func cloneCommands(cmds []Command) []Command {
+	out := make([]Command, len(cmds))
+	for i, c := range cmds {
+		out[i] = c
+		if c.Fields != nil {
+			out[i].Fields = client.Fields{}
+			for k, v := range c.Fields {
+				out[i].Fields[k] = v
+			}
+		}
+		if c.Params != nil {
+			out[i].Params = map[string]string{}
+			for k, v := range c.Params {
+				out[i].Params[k] = v
+			}
+		}
+	}
+	return out
+}
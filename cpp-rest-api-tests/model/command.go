@@ -0,0 +1,197 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strings"
+
+	"cpp-rest-api-tests/client"
+)
+
+type Kind string
+
+const (
+	Create Kind = "create"
+	Get    Kind = "get"
+	Update Kind = "update"
+	Delete Kind = "delete"
+	Reset  Kind = "reset"
+	Query  Kind = "query"
+)
+
+// Command is one operation in a generated sequence.
+type Command struct {
+	Kind   Kind
+	ID     int
+	Fields client.Fields
+	Params map[string]string
+}
+
+func (c Command) String() string {
+	switch c.Kind {
+	case Create:
+		return fmt.Sprintf("POST /records %s", c.body())
+	case Get:
+		return fmt.Sprintf("GET /records/%d", c.ID)
+	case Update:
+		return fmt.Sprintf("PUT /records/%d %s", c.ID, c.body())
+	case Delete:
+		return fmt.Sprintf("DELETE /records/%d", c.ID)
+	case Reset:
+		return "DELETE /reset"
+	}
+	return "GET " + c.path()
+}
+
+func (c Command) method() string {
+	switch c.Kind {
+	case Create:
+		return "POST"
+	case Update:
+		return "PUT"
+	case Delete, Reset:
+		return "DELETE"
+	}
+	return "GET"
+}
+
+func (c Command) path() string {
+	switch c.Kind {
+	case Create:
+		return "/records"
+	case Reset:
+		return "/reset"
+	case Query:
+		q := url.Values{}
+		for k, v := range c.Params {
+			q.Set(k, v)
+		}
+		if len(q) == 0 {
+			return "/records"
+		}
+		return "/records?" + strings.ReplaceAll(q.Encode(), "+", "%20")
+	}
+	return fmt.Sprintf("/records/%d", c.ID)
+}
+
+func (c Command) body() []byte {
+	if c.Kind != Create && c.Kind != Update {
+		return nil
+	}
+	fields := c.Fields
+	if fields == nil {
+		fields = client.Fields{}
+	}
+	data, _ := json.Marshal(fields)
+	return data
+}
+
+// Apply runs c against the model and returns the predicted answer.
+func (m *Model) Apply(c Command) Expected {
+	switch c.Kind {
+	case Create:
+		return m.create(c.Fields)
+	case Get:
+		return m.get(c.ID)
+	case Update:
+		return m.update(c.ID, c.Fields)
+	case Delete:
+		return m.delete(c.ID)
+	case Reset:
+		return m.reset()
+	}
+	return m.query(c.Params)
+}
+
+var values = map[string][]string{
+	"first_name":  {"John", "Jane", "Sarah", ""},
+	"middle_name": {"", "Q"},
+	"last_name":   {"Doe", "Smith"},
+	"street":      {"123 Main St", "9 Oak Rd"},
+	"city":        {"Springfield", "Anytown", ""},
+	"state":       {"CA", "NY"},
+	"zip":         {"12345", "90210"},
+	"phone":       {"5551234567", "5559876543", "4151234567"},
+	"email":       {"john@example.com", "jane@example.com"},
+}
+
+// Generate builds a reproducible sequence of n commands from seed. A
+// scratch model tracks which IDs exist so most commands hit live records.
+func Generate(seed int64, n int) []Command {
+	rnd := rand.New(rand.NewSource(seed))
+	m := New()
+	cmds := make([]Command, 0, n)
+	for len(cmds) < n {
+		var c Command
+		switch p := rnd.Intn(100); {
+		case p < 30:
+			c = Command{Kind: Create, Fields: randomFields(rnd, rnd.Intn(len(client.FieldNames))+1)}
+		case p < 40:
+			c = Command{Kind: Get, ID: pickID(rnd, m)}
+		case p < 60:
+			c = Command{Kind: Update, ID: pickID(rnd, m), Fields: randomFields(rnd, rnd.Intn(3)+1)}
+		case p < 72:
+			c = Command{Kind: Delete, ID: pickID(rnd, m)}
+		case p < 75:
+			c = Command{Kind: Reset}
+		default:
+			c = Command{Kind: Query, Params: randomParams(rnd, m)}
+		}
+		m.Apply(c)
+		cmds = append(cmds, c)
+	}
+	return cmds
+}
+
+func pickID(rnd *rand.Rand, m *Model) int {
+	if len(m.Records) == 0 || rnd.Intn(10) == 0 {
+		return rnd.Intn(m.NextID+2) + 1
+	}
+	return m.Records[rnd.Intn(len(m.Records))].ID
+}
+
+func randomFields(rnd *rand.Rand, count int) client.Fields {
+	fields := client.Fields{}
+	for _, i := range rnd.Perm(len(client.FieldNames))[:count] {
+		name := client.FieldNames[i]
+		fields[name] = values[name][rnd.Intn(len(values[name]))]
+	}
+	return fields
+}
+
+func randomParams(rnd *rand.Rand, m *Model) map[string]string {
+	params := map[string]string{}
+	for _, i := range rnd.Perm(len(client.FieldNames))[:rnd.Intn(3)] {
+		name := client.FieldNames[i]
+		v := values[name][rnd.Intn(len(values[name]))]
+		if name == "phone" && rnd.Intn(2) == 0 {
+			v = v[:3]
+		}
+		params[name] = v
+	}
+	if len(m.Records) > 0 && rnd.Intn(6) == 0 {
+		params["id"] = fmt.Sprint(m.Records[rnd.Intn(len(m.Records))].ID)
+	}
+	return params
+}
+
+// Format renders a sequence one command per line, for failure reports.
+func Format(cmds []Command) string {
+	lines := make([]string, len(cmds))
+	for i, c := range cmds {
+		lines[i] = fmt.Sprintf("%3d. %s", i+1, c)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,124 @@
+// Package model is an in-memory oracle for the contacts API: a Go
+// transcription of the std::vector<Record> and next_id that ApiHandler
+// operates on. Commands are applied to the model and to a live server and
+// the answers compared; see Check and Shrink.
+package model
+
+import (
+	"net/http"
+	"strconv"
+
+	"cpp-rest-api-tests/client"
+)
+
+// Model predicts the server's answers.
+type Model struct {
+	Records []client.Contact
+	NextID  int
+}
+
+func New() *Model {
+	return &Model{NextID: 1}
+}
+
+// Expected is the answer the model predicts for a command. Exactly one of
+// Record, Records and Text describes the body.
+type Expected struct {
+	Status  int
+	Record  *client.Contact
+	Records []client.Contact
+	Text    string
+}
+
+func (m *Model) find(id int) int {
+	for i, r := range m.Records {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func notFound() Expected {
+	return Expected{Status: http.StatusNotFound, Text: "Record not found"}
+}
+
+func (m *Model) create(fields client.Fields) Expected {
+	r := client.Contact{ID: m.NextID}
+	m.NextID++
+	for name, v := range fields {
+		r.Set(name, v)
+	}
+	m.Records = append(m.Records, r)
+	return Expected{Status: http.StatusCreated, Record: &r}
+}
+
+func (m *Model) get(id int) Expected {
+	i := m.find(id)
+	if i < 0 {
+		return notFound()
+	}
+	r := m.Records[i]
+	return Expected{Status: http.StatusOK, Record: &r}
+}
+
+func (m *Model) update(id int, fields client.Fields) Expected {
+	i := m.find(id)
+	if i < 0 {
+		return notFound()
+	}
+	for name, v := range fields {
+		m.Records[i].Set(name, v)
+	}
+	r := m.Records[i]
+	return Expected{Status: http.StatusOK, Record: &r}
+}
+
+func (m *Model) delete(id int) Expected {
+	i := m.find(id)
+	if i < 0 {
+		return notFound()
+	}
+	m.Records = append(m.Records[:i], m.Records[i+1:]...)
+	return Expected{Status: http.StatusNoContent}
+}
+
+func (m *Model) reset() Expected {
+	m.Records = nil
+	m.NextID = 1
+	return Expected{Status: http.StatusNoContent}
+}
+
+func (m *Model) query(params map[string]string) Expected {
+	matches := []client.Contact{}
+	for _, r := range m.Records {
+		if matchRecord(r, params) {
+			matches = append(matches, r)
+		}
+	}
+	return Expected{Status: http.StatusOK, Records: matches}
+}
+
+// matchRecord encodes the documented query rules: every parameter must match
+// exactly, except that a three-character phone also matches the area code.
+func matchRecord(r client.Contact, params map[string]string) bool {
+	for name, want := range params {
+		if name == "id" {
+			if strconv.Itoa(r.ID) != want {
+				return false
+			}
+			continue
+		}
+		f := r.Field(name)
+		if f == nil {
+			continue
+		}
+		if name == "phone" && len(want) == 3 && len(*f) >= 3 && (*f)[:3] == want {
+			continue
+		}
+		if *f != want {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/refapi"
+	"cpp-rest-api-tests/testenv"
+)
+
+var (
+	targetFlags = config.BindFlags(flag.CommandLine)
+	seedFlag    = flag.Int64("model.seed", 0, "seed for the first generated sequence (default: time based)")
+	runsFlag    = flag.Int("model.runs", 20, "number of sequences to check")
+	stepsFlag   = flag.Int("model.steps", 60, "commands per sequence")
+)
+
+var api *client.Client
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { api = env.API })
+}
+
+func TestServerAgreesWithModel(t *testing.T) {
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	ctx := context.Background()
+	for run := 0; run < *runsFlag; run++ {
+		s := seed + int64(run)
+		cmds := Generate(s, *stepsFlag)
+		err := Check(ctx, api, cmds)
+		if err == nil {
+			continue
+		}
+		var mismatch *Mismatch
+		if !errors.As(err, &mismatch) {
+			t.Fatalf("seed %d: %v", s, err)
+		}
+		minimal := Shrink(ctx, api, cmds)
+		t.Fatalf("seed %d: %v\n\nminimal reproduction (%d of %d commands):\n%s\n\nreplay with: go test ./model -args -model.seed=%d -model.runs=1 -model.steps=%d",
+			s, mismatch, len(minimal), len(cmds), Format(minimal), s, *stepsFlag)
+	}
+}
+
+func TestShrinkFindsMinimalSequence(t *testing.T) {
+	// A server that forgets phone area-code matching.
+	ref := refapi.NewHandler()
+	buggy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); len(q.Get("phone")) == 3 {
+			q.Set("phone", q.Get("phone")+"-")
+			r.URL.RawQuery = q.Encode()
+		}
+		ref.ServeHTTP(w, r)
+	}))
+	defer buggy.Close()
+	c := client.New(buggy.URL, nil)
+	ctx := context.Background()
+
+	for seed := int64(1); seed < 200; seed++ {
+		cmds := Generate(seed, 80)
+		if Check(ctx, c, cmds) == nil {
+			continue
+		}
+		minimal := Shrink(ctx, c, cmds)
+		if len(minimal) != 2 || minimal[0].Kind != Create || minimal[1].Kind != Query || len(minimal[1].Params) != 1 {
+			t.Fatalf("seed %d shrank to:\n%s", seed, Format(minimal))
+		}
+		return
+	}
+	t.Fatal("no generated sequence exposed the bug")
+}
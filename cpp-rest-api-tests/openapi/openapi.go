@@ -0,0 +1,269 @@
+// Package openapi checks exchanges with the contacts API against
+// openapi.json, the OpenAPI 3 description of main.cpp kept next to this
+// file. It understands the subset of OpenAPI the document uses: path and
+// query parameters, JSON and plain-text bodies, and schemas built from
+// type, properties, required, additionalProperties, items, enum, minimum
+// and local $refs.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed openapi.json
+var document []byte
+
+// Document returns the embedded OpenAPI document.
+func Document() []byte {
+	return append([]byte(nil), document...)
+}
+
+// Spec is a parsed OpenAPI document.
+type Spec struct {
+	Operations []*Operation
+
+	schemas   map[string]*Schema
+	responses map[string]*Response
+	unmatched map[string]*Response
+	// missingContentType is true when the document allows responses with
+	// no Content-Type header, which is how Pistache's send() answers.
+	missingContentType bool
+	// requestTypeIgnored is true when request bodies are parsed the same
+	// whatever their Content-Type says, as the handlers in main.cpp do.
+	requestTypeIgnored bool
+}
+
+// Operation is one method on one path template.
+type Operation struct {
+	ID          string
+	Method      string
+	Path        string
+	Parameters  []Parameter
+	RequestBody *RequestBody
+	Responses   map[string]*Response
+
+	segments []string
+}
+
+func (o *Operation) String() string {
+	return fmt.Sprintf("%s (%s %s)", o.ID, o.Method, o.Path)
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref"`
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []interface{}      `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+}
+
+type rawOperation struct {
+	OperationID string               `json:"operationId"`
+	Parameters  []Parameter          `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type rawDocument struct {
+	OpenAPI            string                                `json:"openapi"`
+	Paths              map[string]map[string]json.RawMessage `json:"paths"`
+	MissingContentType string                                `json:"x-missing-content-type"`
+	RequestContentType string                                `json:"x-request-content-type"`
+	UnmatchedRoute     map[string]*Response                  `json:"x-unmatched-route"`
+	Components         struct {
+		Schemas   map[string]*Schema   `json:"schemas"`
+		Responses map[string]*Response `json:"responses"`
+	} `json:"components"`
+}
+
+var methods = map[string]string{
+	"get": "GET", "put": "PUT", "post": "POST", "delete": "DELETE",
+	"patch": "PATCH", "head": "HEAD", "options": "OPTIONS",
+}
+
+// Load parses the embedded document.
+func Load() (*Spec, error) {
+	return Parse(document)
+}
+
+// MustLoad is Load for callers that cannot continue without the spec.
+func MustLoad() *Spec {
+	spec, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return spec
+}
+
+// Parse reads an OpenAPI 3 document.
+func Parse(data []byte) (*Spec, error) {
+	var doc rawDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("openapi: failed to parse document: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("openapi: unsupported version %q", doc.OpenAPI)
+	}
+	spec := &Spec{
+		schemas:            doc.Components.Schemas,
+		responses:          doc.Components.Responses,
+		unmatched:          doc.UnmatchedRoute,
+		missingContentType: doc.MissingContentType == "allowed",
+		requestTypeIgnored: doc.RequestContentType == "ignored",
+	}
+
+	for path, item := range doc.Paths {
+		var shared []Parameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("openapi: %s parameters: %v", path, err)
+			}
+		}
+		for key, raw := range item {
+			method, ok := methods[key]
+			if !ok {
+				continue
+			}
+			var op rawOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("openapi: %s %s: %v", method, path, err)
+			}
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("openapi: %s %s has no operationId", method, path)
+			}
+			spec.Operations = append(spec.Operations, &Operation{
+				ID:          op.OperationID,
+				Method:      method,
+				Path:        path,
+				Parameters:  append(append([]Parameter(nil), shared...), op.Parameters...),
+				RequestBody: op.RequestBody,
+				Responses:   op.Responses,
+				segments:    segments(path),
+			})
+		}
+	}
+
+	// Literal segments win over parameters, as they do in the router.
+	sort.Slice(spec.Operations, func(i, j int) bool {
+		a, b := spec.Operations[i], spec.Operations[j]
+		if pa, pb := params(a.segments), params(b.segments); pa != pb {
+			return pa < pb
+		}
+		return a.Path+a.Method < b.Path+b.Method
+	})
+
+	for _, op := range spec.Operations {
+		for code, resp := range op.Responses {
+			if _, err := spec.response(resp); err != nil {
+				return nil, fmt.Errorf("openapi: %s response %s: %v", op, code, err)
+			}
+		}
+	}
+	return spec, nil
+}
+
+// Find returns the operation serving method and path along with its path
+// parameters, or nil when no route matches. Repeated and trailing slashes
+// are ignored, as in the server's router.
+func (s *Spec) Find(method, path string) (*Operation, map[string]string) {
+	segs := segments(path)
+	for _, op := range s.Operations {
+		if op.Method != method || len(op.segments) != len(segs) {
+			continue
+		}
+		values := map[string]string{}
+		ok := true
+		for i, seg := range op.segments {
+			if name, isParam := paramName(seg); isParam {
+				values[name] = segs[i]
+			} else if seg != segs[i] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return op, values
+		}
+	}
+	return nil, nil
+}
+
+func (s *Spec) response(r *Response) (*Response, error) {
+	if r == nil || r.Ref == "" {
+		return r, nil
+	}
+	name := strings.TrimPrefix(r.Ref, "#/components/responses/")
+	resolved, ok := s.responses[name]
+	if !ok || name == r.Ref {
+		return nil, fmt.Errorf("unresolved $ref %q", r.Ref)
+	}
+	return resolved, nil
+}
+
+func (s *Spec) schema(sc *Schema) (*Schema, error) {
+	for depth := 0; sc != nil && sc.Ref != ""; depth++ {
+		name := strings.TrimPrefix(sc.Ref, "#/components/schemas/")
+		resolved, ok := s.schemas[name]
+		if !ok || name == sc.Ref || depth > 16 {
+			return nil, fmt.Errorf("unresolved $ref %q", sc.Ref)
+		}
+		sc = resolved
+	}
+	return sc, nil
+}
+
+func segments(path string) []string {
+	var segs []string
+	for _, seg := range strings.Split(path, "/") {
+		if seg != "" {
+			segs = append(segs, seg)
+		}
+	}
+	return segs
+}
+
+func paramName(seg string) (string, bool) {
+	if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+		return seg[1 : len(seg)-1], true
+	}
+	return "", false
+}
+
+func params(segs []string) int {
+	n := 0
+	for _, seg := range segs {
+		if _, ok := paramName(seg); ok {
+			n++
+		}
+	}
+	return n
+}
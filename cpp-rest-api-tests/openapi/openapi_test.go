@@ -0,0 +1,175 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/refapi"
+)
+
+func TestDocumentDescribesEveryRoute(t *testing.T) {
+	spec := MustLoad()
+	want := map[string]string{
+		"GET /records":         "queryRecords",
+		"POST /records":        "createRecord",
+		"GET /records/{id}":    "getRecord",
+		"PUT /records/{id}":    "updateRecord",
+		"DELETE /records/{id}": "deleteRecord",
+		"DELETE /reset":        "resetRecords",
+	}
+	if len(spec.Operations) != len(want) {
+		t.Fatalf("got %d operations, want %d", len(spec.Operations), len(want))
+	}
+	for _, op := range spec.Operations {
+		if id := want[op.Method+" "+op.Path]; id != op.ID {
+			t.Fatalf("%s %s: operationId %q, want %q", op.Method, op.Path, op.ID, id)
+		}
+	}
+	if op, params := spec.Find("GET", "//records/7/"); op == nil || op.ID != "getRecord" || params["id"] != "7" {
+		t.Fatalf("Find(GET //records/7/) = %v, %v", op, params)
+	}
+	if op, _ := spec.Find("PATCH", "/records/7"); op != nil {
+		t.Fatalf("Find(PATCH) = %v, want no route", op)
+	}
+}
+
+// The reference server is the executable spec of main.cpp, so every
+// exchange with it must conform, including the error paths.
+func TestReferenceServerConforms(t *testing.T) {
+	srv, _ := refapi.NewServer()
+	defer srv.Close()
+	api := client.New(srv.URL, Wrap(srv.Client(), MustLoad()))
+	ctx := context.Background()
+
+	exchanges := []struct{ method, path, body string }{
+		{"POST", "/records", `{"first_name":"John","phone":"5551234567","nickname":"x"}`},
+		{"POST", "/records", `{"first_name":5}`},
+		{"POST", "/records", `not json`},
+		{"GET", "/records", ""},
+		{"GET", "/records/", ""},
+		{"GET", "/records?phone=555&id=1", ""},
+		{"GET", "/records/1", ""},
+		{"GET", "/records/abc", ""},
+		{"PUT", "/records/99999999999", `{}`},
+		{"GET", "/records/99", ""},
+		{"PUT", "/records/1", `{"city":"Springfield"}`},
+		{"PUT", "/records/1", `{"city":null}`},
+		{"PUT", "/records/99", `{}`},
+		{"PATCH", "/records/1", `{}`},
+		{"GET", "/reset", ""},
+		{"HEAD", "/records", ""},
+		{"DELETE", "/records/1", ""},
+		{"DELETE", "/records/1", ""},
+		{"DELETE", "/reset", ""},
+	}
+	for _, x := range exchanges {
+		var body []byte
+		if x.body != "" {
+			body = []byte(x.body)
+		}
+		if _, err := api.Do(ctx, x.method, x.path, body); err != nil {
+			t.Fatalf("%s %s: %v", x.method, x.path, err)
+		}
+	}
+	// main.cpp parses the body whatever the request says it is.
+	if _, err := api.DoWithHeaders(ctx, "POST", "/records", map[string]string{"Content-Type": "text/plain"}, []byte(`{"first_name":"Ann"}`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDriftIsReported(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		body    string
+		want    []string
+	}{
+		{
+			name: "missing field",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				noContentType(w)
+				fmt.Fprint(w, `{"id":1,"first_name":"John","middle_name":"","last_name":"","street":"","city":"","state":"","zip":"","phone":""}`)
+			},
+			method: "GET",
+			want:   []string{"getRecord (GET /records/1) -> 200", "$.email: missing required property"},
+		},
+		{
+			name: "wrong type",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				noContentType(w)
+				fmt.Fprint(w, `{"id":"1","first_name":"John","middle_name":"","last_name":"","street":"","city":"","state":"","zip":"","phone":"","email":"","extra":1}`)
+			},
+			method: "GET",
+			want:   []string{"$.id: expected integer, got string", "$.extra: unexpected property"},
+		},
+		{
+			name: "wrong content type",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, "Record not found")
+			},
+			method: "GET",
+			want:   []string{`Content-Type "text/html" is not declared (want text/plain)`},
+		},
+		{
+			name: "changed error text",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				noContentType(w)
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, "Not Found")
+			},
+			method: "DELETE",
+			want:   []string{"deleteRecord (DELETE /records/1) -> 404", `"Not Found" is not one of ["Record not found"]`},
+		},
+		{
+			name: "undocumented status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			method: "DELETE",
+			want:   []string{"undocumented status 502 (documented: 204, 404, 500)"},
+		},
+		{
+			name: "accepted invalid body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				noContentType(w)
+				fmt.Fprint(w, `{"id":1,"first_name":"","middle_name":"","last_name":"","street":"","city":"","state":"","zip":"","phone":"","email":""}`)
+			},
+			method: "PUT",
+			body:   `{"first_name":5}`,
+			want:   []string{"updateRecord", "accepted a request the spec rejects: request body $.first_name: expected string, got integer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+			api := client.New(srv.URL, Wrap(srv.Client(), MustLoad()))
+			var body []byte
+			if tt.body != "" {
+				body = []byte(tt.body)
+			}
+			_, err := api.Do(context.Background(), tt.method, "/records/1", body)
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// noContentType stops net/http from sniffing one, to answer like Pistache.
+func noContentType(w http.ResponseWriter) {
+	w.Header()["Content-Type"] = nil
+}
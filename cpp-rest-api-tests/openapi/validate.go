@@ -0,0 +1,415 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Exchange is one request and the response it got.
+type Exchange struct {
+	Method      string
+	URL         *url.URL
+	RequestType string // Content-Type of the request
+	RequestBody []byte
+
+	StatusCode   int
+	ResponseType string // Content-Type of the response
+	ResponseBody []byte
+}
+
+// Violation describes how an exchange departs from the spec.
+type Violation struct {
+	Operation  string // operationId, or "unmatched route"
+	Method     string
+	Path       string
+	StatusCode int
+	Problems   []string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("openapi: %s (%s %s) -> %d: %s", v.Operation, v.Method, v.Path, v.StatusCode, strings.Join(v.Problems, "; "))
+}
+
+// Check validates an exchange and returns a *Violation, or nil when it
+// conforms. A request the spec does not allow is fine as long as the
+// server rejected it with a 4xx or a documented 5xx; accepting it is drift.
+func (s *Spec) Check(x Exchange) error {
+	op, pathParams := s.Find(x.Method, x.URL.Path)
+	v := &Violation{Operation: "unmatched route", Method: x.Method, Path: x.URL.Path, StatusCode: x.StatusCode}
+	responses := s.unmatched
+	var requestProblems []string
+	if op != nil {
+		v.Operation = op.ID
+		responses = op.Responses
+		requestProblems = s.checkRequest(op, pathParams, x)
+	}
+
+	resp, ok := responses[strconv.Itoa(x.StatusCode)]
+	if !ok {
+		resp, ok = responses["default"]
+	}
+	if !ok {
+		v.Problems = append(v.Problems, fmt.Sprintf("undocumented status %d (documented: %s)", x.StatusCode, strings.Join(codes(responses), ", ")))
+	} else {
+		v.Problems = append(v.Problems, s.checkResponse(resp, x)...)
+	}
+
+	if len(requestProblems) > 0 && (x.StatusCode < 400 || (x.StatusCode >= 500 && !ok)) {
+		for _, p := range requestProblems {
+			v.Problems = append(v.Problems, "accepted a request the spec rejects: "+p)
+		}
+	}
+	if len(v.Problems) == 0 {
+		return nil
+	}
+	return v
+}
+
+func (s *Spec) checkRequest(op *Operation, pathParams map[string]string, x Exchange) []string {
+	var problems []string
+	query := x.URL.Query()
+	for _, p := range op.Parameters {
+		var values []string
+		switch p.In {
+		case "path":
+			values = []string{pathParams[p.Name]}
+		case "query":
+			values = query[p.Name]
+		default:
+			continue
+		}
+		if len(values) == 0 {
+			if p.Required {
+				problems = append(problems, fmt.Sprintf("missing %s parameter %q", p.In, p.Name))
+			}
+			continue
+		}
+		for _, value := range values {
+			if err := s.checkParam(p.Schema, value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s parameter %q: %v", p.In, p.Name, err))
+			}
+		}
+	}
+
+	if op.RequestBody == nil {
+		return problems
+	}
+	if len(x.RequestBody) == 0 {
+		if op.RequestBody.Required {
+			problems = append(problems, "missing request body")
+		}
+		return problems
+	}
+	requestType := x.RequestType
+	if s.requestTypeIgnored {
+		requestType = ""
+	}
+	mt, err := pickMediaType(op.RequestBody.Content, requestType, s.missingContentType || s.requestTypeIgnored)
+	if err != nil {
+		return append(problems, "request "+err.Error())
+	}
+	return append(problems, s.checkBody("request body", op.RequestBody.Content[mt].Schema, mt, x.RequestBody)...)
+}
+
+func (s *Spec) checkParam(sc *Schema, value string) error {
+	sc, err := s.schema(sc)
+	if err != nil || sc == nil {
+		return err
+	}
+	var v interface{} = value
+	switch sc.Type {
+	case "integer", "number":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || (sc.Type == "integer" && strings.ContainsAny(value, ".eE")) {
+			return fmt.Errorf("%q is not %s %s", value, article(sc.Type), sc.Type)
+		}
+		v = f
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		v = b
+	}
+	var problems []string
+	s.validate(sc, v, "", &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s *Spec) checkResponse(resp *Response, x Exchange) []string {
+	resp, err := s.response(resp)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	// A response to HEAD describes the GET body it leaves out.
+	if len(resp.Content) == 0 || x.Method == http.MethodHead {
+		if len(x.ResponseBody) > 0 {
+			return []string{fmt.Sprintf("expected no body, got %d bytes", len(x.ResponseBody))}
+		}
+		return nil
+	}
+	mt, err := pickMediaType(resp.Content, x.ResponseType, s.missingContentType)
+	if err != nil {
+		return []string{"response " + err.Error()}
+	}
+	return s.checkBody("response body", resp.Content[mt].Schema, mt, x.ResponseBody)
+}
+
+// pickMediaType matches a Content-Type header against the declared media
+// types. An empty header picks the only declared type when allowed.
+func pickMediaType(content map[string]MediaType, header string, allowMissing bool) (string, error) {
+	declared := make([]string, 0, len(content))
+	for mt := range content {
+		declared = append(declared, mt)
+	}
+	sort.Strings(declared)
+
+	if header == "" {
+		if allowMissing && len(declared) == 1 {
+			return declared[0], nil
+		}
+		return "", fmt.Errorf("has no Content-Type (want %s)", strings.Join(declared, " or "))
+	}
+	mt, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return "", fmt.Errorf("has malformed Content-Type %q", header)
+	}
+	if _, ok := content[mt]; !ok {
+		return "", fmt.Errorf("Content-Type %q is not declared (want %s)", mt, strings.Join(declared, " or "))
+	}
+	return mt, nil
+}
+
+func (s *Spec) checkBody(what string, sc *Schema, mediaType string, body []byte) []string {
+	var v interface{}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if err := json.Unmarshal(body, &v); err != nil {
+			return []string{fmt.Sprintf("%s is not JSON: %v", what, err)}
+		}
+	case strings.HasPrefix(mediaType, "text/"):
+		v = string(body)
+	default:
+		return nil
+	}
+	var problems []string
+	s.validate(sc, v, "$", &problems)
+	for i, p := range problems {
+		problems[i] = what + " " + p
+	}
+	return problems
+}
+
+// validate appends a problem for every place v departs from sc. at is the
+// JSONPath-style location reported with each problem.
+func (s *Spec) validate(sc *Schema, v interface{}, at string, problems *[]string) {
+	sc, err := s.schema(sc)
+	if err != nil {
+		*problems = append(*problems, err.Error())
+		return
+	}
+	if sc == nil {
+		return
+	}
+	where := func() string {
+		if at == "" {
+			return ""
+		}
+		return at + ": "
+	}
+
+	if sc.Type != "" && !hasType(v, sc.Type) {
+		*problems = append(*problems, fmt.Sprintf("%sexpected %s, got %s", where(), sc.Type, jsonType(v)))
+		return
+	}
+	if len(sc.Enum) > 0 && !inEnum(v, sc.Enum) {
+		*problems = append(*problems, fmt.Sprintf("%s%s is not one of %s", where(), quote(v), enumList(sc.Enum)))
+	}
+	if sc.Minimum != nil {
+		if f, ok := v.(float64); ok && f < *sc.Minimum {
+			*problems = append(*problems, fmt.Sprintf("%s%v is below the minimum %v", where(), f, *sc.Minimum))
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, name := range sc.Required {
+			if _, ok := v[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s.%s: missing required property", at, name))
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := sc.Properties[name]; ok {
+				s.validate(prop, v[name], at+"."+name, problems)
+			} else if sc.AdditionalProperties != nil && !*sc.AdditionalProperties {
+				*problems = append(*problems, fmt.Sprintf("%s.%s: unexpected property", at, name))
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			s.validate(sc.Items, item, fmt.Sprintf("%s[%d]", at, i), problems)
+		}
+	}
+}
+
+func hasType(v interface{}, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	case "null":
+		return v == nil
+	}
+	return true
+}
+
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func inEnum(v interface{}, enum []interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(v) && jsonType(e) == jsonType(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumList(enum []interface{}) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		parts[i] = quote(e)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func quote(v interface{}) string {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(out) > 80 {
+		return string(out[:77]) + "..."
+	}
+	return string(out)
+}
+
+func article(typ string) string {
+	if typ == "integer" {
+		return "an"
+	}
+	return "a"
+}
+
+func codes(responses map[string]*Response) []string {
+	out := make([]string, 0, len(responses))
+	for code := range responses {
+		out = append(out, code)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Transport is an http.RoundTripper that checks every exchange against
+// Spec and fails the request with a *Violation when it departs from it.
+type Transport struct {
+	Spec *Spec
+	Base http.RoundTripper
+}
+
+// Wrap returns a copy of c whose requests are checked against spec.
+func Wrap(c *http.Client, spec *Spec) *http.Client {
+	wrapped := *c
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped.Transport = &Transport{Spec: spec, Base: base}
+	return &wrapped
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	err = t.Spec.Check(Exchange{
+		Method:       req.Method,
+		URL:          req.URL,
+		RequestType:  req.Header.Get("Content-Type"),
+		RequestBody:  reqBody,
+		StatusCode:   resp.StatusCode,
+		ResponseType: resp.Header.Get("Content-Type"),
+		ResponseBody: respBody,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,223 @@
+// Package queries pins down how GET /records filters, case by case,
+// against a fixed dataset.
+//
+// ApiHandler::query compares every known parameter, and the undocumented
+// id, exactly and case-sensitively with the stored value. The exception is
+// phone: a 3-character value also matches as an area-code prefix, while
+// any other length must equal the whole number. An empty value matches
+// only blank fields, parameters it does not know are ignored, and a
+// repeated parameter uses its first value. Reference lists one case per
+// consequence, so a change to the query engine breaks a named rule rather
+// than an unrelated scenario.
+//
+// Queries are sent as raw query strings, without percent-encoding, so that
+// the cases do not depend on how the server decodes them.
+package queries
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"cpp-rest-api-tests/client"
+)
+
+// Dataset is seeded in this order after a reset. Contacts are known by
+// their first name, which is unique, so the cases read without IDs; Ada
+// gets ID 1.
+var Dataset = []client.Contact{
+	{FirstName: "Ada", LastName: "Lovelace", City: "Springfield", State: "IL", Zip: "62701", Phone: "5551234567", Email: "ada@example.com"},
+	{FirstName: "Alan", LastName: "Turing", City: "springfield", Phone: "5559876543"},
+	{FirstName: "Grace", MiddleName: "Brewster", LastName: "Hopper", City: "Springfield", Phone: "4441234567"},
+	{FirstName: "Linus", City: "", Phone: "555"},
+	{FirstName: "Margaret", LastName: "Hamilton", City: "SPRINGFIELD", Phone: "55512"},
+	{FirstName: "Edsger", LastName: "Dijkstra", City: "Shelbyville", Phone: "5551234567", Email: "EDSGER@example.com"},
+	{FirstName: "Barbara", LastName: "Liskov"},
+}
+
+// Rules, in the order Reference covers them.
+const (
+	RuleID       = "id"
+	RuleExact    = "exact"
+	RuleCase     = "case"
+	RulePhone    = "phone"
+	RuleEmpty    = "empty"
+	RuleUnknown  = "unknown"
+	RuleCombined = "combined"
+	RuleRepeated = "repeated"
+)
+
+// Case is one query and the contacts it returns, in creation order.
+type Case struct {
+	Rule  string
+	Query string // without the leading "?"
+	Want  []string
+}
+
+func (c Case) String() string { return c.Rule + ": ?" + c.Query }
+
+// Everyone stands for every contact in Dataset.
+var Everyone = names(Dataset)
+
+var Reference = []Case{
+	{RuleID, "id=1", []string{"Ada"}},
+	{RuleID, "id=6", []string{"Edsger"}},
+	{RuleID, "id=01", nil},
+	{RuleID, "id=abc", nil},
+	{RuleID, "id=99", nil},
+	{RuleID, "id=", nil},
+	{RuleID, "id=1&first_name=Alan", nil},
+
+	{RuleExact, "city=Springfield", []string{"Ada", "Grace"}},
+	{RuleExact, "city=Spring", nil},
+	{RuleExact, "last_name=Love", nil},
+	{RuleExact, "zip=62701", []string{"Ada"}},
+	{RuleExact, "email=ada@example.com", []string{"Ada"}},
+	{RuleExact, "middle_name=Brewster", []string{"Grace"}},
+
+	{RuleCase, "city=springfield", []string{"Alan"}},
+	{RuleCase, "city=SPRINGFIELD", []string{"Margaret"}},
+	{RuleCase, "first_name=ada", nil},
+	{RuleCase, "state=il", nil},
+	{RuleCase, "email=edsger@example.com", nil},
+
+	{RulePhone, "phone=555", []string{"Ada", "Alan", "Linus", "Margaret", "Edsger"}},
+	{RulePhone, "phone=444", []string{"Grace"}},
+	{RulePhone, "phone=123", nil},
+	{RulePhone, "phone=55", nil},
+	{RulePhone, "phone=5551", nil},
+	{RulePhone, "phone=55512", []string{"Margaret"}},
+	{RulePhone, "phone=555123", nil},
+	{RulePhone, "phone=555123456", nil},
+	{RulePhone, "phone=5551234567", []string{"Ada", "Edsger"}},
+	{RulePhone, "phone=55512345678", nil},
+	{RulePhone, "phone=234", nil},
+
+	{RuleEmpty, "city=", []string{"Linus", "Barbara"}},
+	{RuleEmpty, "phone=", []string{"Barbara"}},
+	{RuleEmpty, "middle_name=", []string{"Ada", "Alan", "Linus", "Margaret", "Edsger", "Barbara"}},
+	{RuleEmpty, "first_name=", nil},
+
+	{RuleUnknown, "nickname=x", Everyone},
+	{RuleUnknown, "City=Springfield", Everyone},
+	{RuleUnknown, "name=Ada", Everyone},
+	{RuleUnknown, "nickname=x&city=Springfield", []string{"Ada", "Grace"}},
+
+	{RuleCombined, "city=Springfield&phone=555", []string{"Ada"}},
+	{RuleCombined, "city=Springfield&phone=444", []string{"Grace"}},
+	{RuleCombined, "phone=5551234567&city=Shelbyville", []string{"Edsger"}},
+	{RuleCombined, "first_name=Ada&last_name=Turing", nil},
+
+	{RuleRepeated, "city=Shelbyville&city=Springfield", []string{"Edsger"}},
+	{RuleRepeated, "phone=444&phone=555", []string{"Grace"}},
+}
+
+// Cases returns the reference cases for rule, or all of them for "".
+func Cases(rule string) []Case {
+	var out []Case
+	for _, c := range Reference {
+		if rule == "" || c.Rule == rule {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Seed resets the store and creates Dataset.
+func Seed(ctx context.Context, api *client.Client) error {
+	if err := api.Reset(ctx); err != nil {
+		return err
+	}
+	for _, c := range Dataset {
+		if _, err := api.Create(ctx, c); err != nil {
+			return fmt.Errorf("seeding %s: %v", c.FirstName, err)
+		}
+	}
+	return nil
+}
+
+// Query sends GET /records?query as written and returns the first names
+// of the contacts in the answer, in order.
+func Query(ctx context.Context, api *client.Client, query string) ([]string, error) {
+	path := "/records"
+	if query != "" {
+		path += "?" + query
+	}
+	resp, err := api.Do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: status %d: %s", path, resp.StatusCode, resp.Body)
+	}
+	var found []client.Contact
+	if err := json.Unmarshal(resp.Body, &found); err != nil {
+		return nil, fmt.Errorf("GET %s: invalid JSON: %v", path, err)
+	}
+	return names(found), nil
+}
+
+// Check runs the cases against a store seeded with Dataset and describes
+// every case whose answer differs.
+func Check(ctx context.Context, api *client.Client, cases []Case) ([]string, error) {
+	var diffs []string
+	for _, c := range cases {
+		got, err := Query(ctx, api, c.Query)
+		if err != nil {
+			return diffs, err
+		}
+		if !equal(got, c.Want) {
+			diffs = append(diffs, fmt.Sprintf("%s returned %s, want %s", c, Format(got), Format(c.Want)))
+		}
+	}
+	return diffs, nil
+}
+
+func names(contacts []client.Contact) []string {
+	out := make([]string, len(contacts))
+	for i, c := range contacts {
+		out[i] = c.FirstName
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Format lists names the way feature files write them: "nothing",
+// "everyone", or comma-separated names.
+func Format(names []string) string {
+	switch {
+	case len(names) == 0:
+		return "nothing"
+	case equal(names, Everyone):
+		return "everyone"
+	}
+	return strings.Join(names, ", ")
+}
+
+// Parse is the inverse of Format.
+func Parse(s string) []string {
+	switch s = strings.TrimSpace(s); s {
+	case "nothing", "":
+		return nil
+	case "everyone":
+		return Everyone
+	}
+	var out []string
+	for _, name := range strings.Split(s, ",") {
+		out = append(out, strings.TrimSpace(name))
+	}
+	return out
+}
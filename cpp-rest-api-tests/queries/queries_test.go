@@ -0,0 +1,96 @@
+package queries
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/refapi"
+	"cpp-rest-api-tests/testenv"
+)
+
+var targetFlags = config.BindFlags(flag.CommandLine)
+
+var api *client.Client
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { api = env.API })
+}
+
+func TestSemantics(t *testing.T) {
+	ctx := context.Background()
+	if err := Seed(ctx, api); err != nil {
+		t.Fatal(err)
+	}
+	defer api.Reset(ctx)
+	for _, c := range Reference {
+		c := c
+		t.Run(c.Rule+"/"+c.Query, func(t *testing.T) {
+			got, err := Query(ctx, api, c.Query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equal(got, c.Want) {
+				t.Errorf("?%s returned %s, want %s", c.Query, Format(got), Format(c.Want))
+			}
+		})
+	}
+}
+
+// Every rule has cases and every case is under a known rule.
+func TestReferenceCoversEveryRule(t *testing.T) {
+	rules := []string{RuleID, RuleExact, RuleCase, RulePhone, RuleEmpty, RuleUnknown, RuleCombined, RuleRepeated}
+	total := 0
+	for _, rule := range rules {
+		n := len(Cases(rule))
+		if n == 0 {
+			t.Errorf("rule %q has no cases", rule)
+		}
+		total += n
+	}
+	if total != len(Reference) {
+		t.Errorf("%d of %d cases are under an unknown rule", len(Reference)-total, len(Reference))
+	}
+}
+
+// A query engine that matches every phone value as a prefix breaks the
+// equality half of the phone rule and nothing else.
+func TestCheckNamesTheBrokenCases(t *testing.T) {
+	ref := refapi.NewHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if phone := q.Get("phone"); len(phone) > 3 {
+			q.Set("phone", phone[:3])
+			r.URL.RawQuery = q.Encode()
+		}
+		ref.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	broken := client.New(srv.URL, nil)
+	if err := Seed(ctx, broken); err != nil {
+		t.Fatal(err)
+	}
+	diffs, err := Check(ctx, broken, Reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	area := "Ada, Alan, Linus, Margaret, Edsger"
+	want := []string{
+		"phone: ?phone=5551 returned " + area + ", want nothing",
+		"phone: ?phone=55512 returned " + area + ", want Margaret",
+		"phone: ?phone=555123 returned " + area + ", want nothing",
+		"phone: ?phone=555123456 returned " + area + ", want nothing",
+		"phone: ?phone=5551234567 returned " + area + ", want Ada, Edsger",
+		"phone: ?phone=55512345678 returned " + area + ", want nothing",
+	}
+	if strings.Join(diffs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("diffs:\n%s\nwant:\n%s", strings.Join(diffs, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,78 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/scenario"
+)
+
+// Steps exposes the query semantics cases to godog scenarios.
+type Steps struct {
+	api *client.Client
+}
+
+func NewSteps(api *client.Client) *Steps {
+	return &Steps{api: api}
+}
+
+func (s *Steps) Register(ctx scenario.Context) {
+	ctx.Step(`^the query semantics dataset is loaded$`, s.theDatasetIsLoaded)
+	ctx.Step(`^querying "\?([^"]*)" should return (nothing|everyone|"[^"]*")$`, s.queryingShouldReturn)
+	ctx.Step(`^these queries should return:$`, s.theseQueriesShouldReturn)
+	ctx.Step(`^every "([^"]*)" query semantics case should hold$`, s.everyRuleCaseShouldHold)
+	ctx.Step(`^every query semantics case should hold$`, s.everyCaseShouldHold)
+}
+
+func (s *Steps) theDatasetIsLoaded() error {
+	return Seed(context.Background(), s.api)
+}
+
+func (s *Steps) queryingShouldReturn(query, want string) error {
+	return s.check([]Case{{Query: query, Want: Parse(strings.Trim(want, `"`))}})
+}
+
+// theseQueriesShouldReturn reads a table with a "query" column, written
+// with its leading "?", and a "returns" column in the form Parse reads.
+func (s *Steps) theseQueriesShouldReturn(table *godog.Table) error {
+	if len(table.Rows) < 2 || len(table.Rows[0].Cells) != 2 ||
+		table.Rows[0].Cells[0].Value != "query" || table.Rows[0].Cells[1].Value != "returns" {
+		return fmt.Errorf(`expected a table with "query" and "returns" columns`)
+	}
+	var cases []Case
+	for _, row := range table.Rows[1:] {
+		query := row.Cells[0].Value
+		if !strings.HasPrefix(query, "?") {
+			return fmt.Errorf("query %q should start with ?", query)
+		}
+		cases = append(cases, Case{Query: query[1:], Want: Parse(row.Cells[1].Value)})
+	}
+	return s.check(cases)
+}
+
+func (s *Steps) everyRuleCaseShouldHold(rule string) error {
+	cases := Cases(rule)
+	if len(cases) == 0 {
+		return fmt.Errorf("no query semantics rule %q", rule)
+	}
+	return s.check(cases)
+}
+
+func (s *Steps) everyCaseShouldHold() error {
+	return s.check(Reference)
+}
+
+func (s *Steps) check(cases []Case) error {
+	diffs, err := Check(context.Background(), s.api, cases)
+	if err != nil {
+		return err
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("%d query(ies) answered differently:\n  %s", len(diffs), strings.Join(diffs, "\n  "))
+	}
+	return nil
+}
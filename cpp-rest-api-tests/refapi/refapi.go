@@ -0,0 +1,423 @@
+// Package refapi is a pure-Go reference implementation of the contacts API
+// in main.cpp. It mirrors ApiHandler route for route, down to the status
+// codes, the plain-text error bodies and the quirks of nlohmann::json and
+// the Pistache router, so the BDD suites can run without the C++ build and
+// so there is an executable spec to compare the C++ server against.
+package refapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cpp-rest-api-tests/client"
+)
+
+// Record mirrors struct Record in main.cpp.
+type Record struct {
+	client.Contact
+}
+
+// MarshalJSON writes the record byte for byte as Record::to_json().dump()
+// does. nlohmann::json sorts object keys, as encoding/json does for maps.
+func (r Record) MarshalJSON() ([]byte, error) {
+	obj := map[string]interface{}{"id": r.ID}
+	for name, v := range r.Fields() {
+		obj[name] = v
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(obj)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), err
+}
+
+// Fields lists the string fields ApiHandler reads from request bodies and
+// query strings, in main.cpp's declaration order.
+var Fields = client.FieldNames
+
+// Error bodies sent by ApiHandler and the Pistache router.
+const (
+	MsgInvalidJSON = "Invalid JSON"
+	MsgNotFound    = "Record not found"
+	MsgNoRoute     = "Could not find a matching route"
+	MsgBadCast     = "Bad lexical cast"
+)
+
+// Handler is the equivalent of ApiHandler plus the router in main().
+type Handler struct {
+	// Log receives the same progress lines main.cpp writes to std::cout.
+	// Nil discards them.
+	Log io.Writer
+
+	mu      sync.Mutex
+	records []Record
+	nextID  int
+}
+
+func NewHandler() *Handler {
+	return &Handler{nextID: 1}
+}
+
+// NewServer serves a fresh Handler from an httptest.Server.
+func NewServer() (*httptest.Server, *Handler) {
+	h := NewHandler()
+	return httptest.NewServer(h), h
+}
+
+// Restart drops all state, as restarting the C++ process would.
+func (h *Handler) Restart() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = nil
+	h.nextID = 1
+}
+
+// Records returns a snapshot of the store.
+func (h *Handler) Records() []Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Record(nil), h.records...)
+}
+
+// NextID returns the ID the next create will assign.
+func (h *Handler) NextID() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.nextID
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	segments := sanitize(req.URL.Path)
+
+	switch {
+	case req.Method == http.MethodPost && len(segments) == 1 && segments[0] == "records":
+		h.create(w, req)
+	case req.Method == http.MethodGet && len(segments) == 1 && segments[0] == "records":
+		h.query(w, req)
+	case len(segments) == 2 && segments[0] == "records" &&
+		(req.Method == http.MethodGet || req.Method == http.MethodPut || req.Method == http.MethodDelete):
+		id, err := paramInt(segments[1])
+		if err != nil {
+			// The handler throws before it logs; Pistache's router answers
+			// any exception with 500 and its what().
+			send(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		switch req.Method {
+		case http.MethodGet:
+			h.read(w, id)
+		case http.MethodPut:
+			h.update(w, req, id)
+		case http.MethodDelete:
+			h.del(w, id)
+		}
+	case req.Method == http.MethodDelete && len(segments) == 1 && segments[0] == "reset":
+		h.reset(w)
+	default:
+		// Pistache answers unknown paths and unbound methods alike.
+		send(w, http.StatusNotFound, MsgNoRoute)
+	}
+}
+
+func (h *Handler) create(w http.ResponseWriter, req *http.Request) {
+	h.logf("[POST /records] Creating record")
+	body, ok := parseBody(req)
+	if !ok {
+		h.logf("[POST /records] ERROR: Invalid JSON - parse error")
+		send(w, http.StatusBadRequest, MsgInvalidJSON)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// r.id = next_id_++ runs before the body.value() calls, so a body that
+	// parses but has a non-string field still consumes an ID.
+	r := Record{client.Contact{ID: h.nextID}}
+	h.nextID++
+	for _, name := range Fields {
+		v, err := stringValue(body, name, "")
+		if err != nil {
+			h.logf("[POST /records] ERROR: Invalid JSON - %v", err)
+			send(w, http.StatusBadRequest, MsgInvalidJSON)
+			return
+		}
+		*r.Field(name) = v
+	}
+
+	h.records = append(h.records, r)
+	h.logf("[POST /records] Created record ID: %d", r.ID)
+	sendJSON(w, http.StatusCreated, r)
+}
+
+func (h *Handler) read(w http.ResponseWriter, id int) {
+	h.logf("[GET /records/%d] Reading record", id)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i := h.find(id)
+	if i < 0 {
+		h.logf("[GET /records/%d] ERROR: Record not found", id)
+		send(w, http.StatusNotFound, MsgNotFound)
+		return
+	}
+	h.logf("[GET /records/%d] Found record", id)
+	sendJSON(w, http.StatusOK, h.records[i])
+}
+
+func (h *Handler) update(w http.ResponseWriter, req *http.Request, id int) {
+	h.logf("[PUT /records/%d] Updating record", id)
+	body, ok := parseBody(req)
+	if !ok {
+		h.logf("[PUT /records/%d] ERROR: Invalid JSON - parse error", id)
+		send(w, http.StatusBadRequest, MsgInvalidJSON)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i := h.find(id)
+	if i < 0 {
+		h.logf("[PUT /records/%d] ERROR: Record not found", id)
+		send(w, http.StatusNotFound, MsgNotFound)
+		return
+	}
+
+	// Fields are assigned one by one, so a type error half way through
+	// leaves the earlier fields updated.
+	r := &h.records[i]
+	for _, name := range Fields {
+		v, err := stringValue(body, name, *r.Field(name))
+		if err != nil {
+			h.logf("[PUT /records/%d] ERROR: Invalid JSON - %v", id, err)
+			send(w, http.StatusBadRequest, MsgInvalidJSON)
+			return
+		}
+		*r.Field(name) = v
+	}
+
+	h.logf("[PUT /records/%d] Updated record", id)
+	sendJSON(w, http.StatusOK, *r)
+}
+
+func (h *Handler) del(w http.ResponseWriter, id int) {
+	h.logf("[DELETE /records/%d] Deleting record", id)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i := h.find(id)
+	if i < 0 {
+		h.logf("[DELETE /records/%d] ERROR: Record not found", id)
+		send(w, http.StatusNotFound, MsgNotFound)
+		return
+	}
+	h.records = append(h.records[:i], h.records[i+1:]...)
+	h.logf("[DELETE /records/%d] Deleted record", id)
+	send(w, http.StatusNoContent, "")
+}
+
+func (h *Handler) reset(w http.ResponseWriter) {
+	h.logf("[DELETE /reset] Resetting database")
+	h.mu.Lock()
+	h.records = nil
+	h.nextID = 1
+	h.mu.Unlock()
+	h.logf("[DELETE /reset] Database cleared")
+	send(w, http.StatusNoContent, "")
+}
+
+func (h *Handler) query(w http.ResponseWriter, req *http.Request) {
+	h.logf("[GET /records] Flexible query started")
+	params := req.URL.Query()
+
+	h.mu.Lock()
+	results := make([]Record, 0)
+	for _, r := range h.records {
+		if Matches(r, params) {
+			results = append(results, r)
+		}
+	}
+	h.mu.Unlock()
+
+	h.logf("[GET /records] Found %d matching records", len(results))
+	sendJSON(w, http.StatusOK, results)
+}
+
+// Matches reports whether r satisfies the query the way ApiHandler::query
+// does: exact, case-sensitive matches on id and every field, except that a
+// 3-character phone value also matches as a prefix. Unknown parameters are
+// ignored and repeated ones use their first value.
+func Matches(r Record, params map[string][]string) bool {
+	if v, ok := first(params, "id"); ok && strconv.Itoa(r.ID) != v {
+		return false
+	}
+	for _, name := range Fields {
+		v, ok := first(params, name)
+		if !ok {
+			continue
+		}
+		actual := *r.Field(name)
+		if name == "phone" {
+			if !(actual == v || (len(v) == 3 && prefix(actual, 3) == v)) {
+				return false
+			}
+			continue
+		}
+		if actual != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *Handler) find(id int) int {
+	for i, r := range h.records {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (h *Handler) logf(format string, args ...interface{}) {
+	if h.Log != nil {
+		fmt.Fprintf(h.Log, format+"\n", args...)
+	}
+}
+
+// parseBody is json::parse(request.body()): any JSON value is accepted here,
+// the type checks happen later in stringValue.
+func parseBody(req *http.Request) (interface{}, bool) {
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var body interface{}
+	if err := dec.Decode(&body); err != nil {
+		return nil, false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, false
+	}
+	return body, true
+}
+
+// stringValue is body.value(name, def): non-objects and non-string values
+// throw type errors, a missing key yields def.
+func stringValue(body interface{}, name, def string) (string, error) {
+	obj, ok := body.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("[json.exception.type_error.306] cannot use value() with %s", typeName(body))
+	}
+	v, ok := obj[name]
+	if !ok {
+		return def, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("[json.exception.type_error.302] type must be string, but is %s", typeName(v))
+	}
+	return s, nil
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// sanitize splits a path the way Pistache's router does, ignoring empty
+// segments so "/records/" and "//records" both route to "/records".
+func sanitize(path string) []string {
+	var segments []string
+	for _, s := range strings.Split(path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// paramInt behaves like TypedParam::as<int>(), Pistache's LexicalCast: a
+// std::istringstream >> int that throws "Bad lexical cast" when the stream
+// fails. Leading whitespace is skipped and trailing junk is ignored, so
+// "12abc" is 12, but no digits at all or a value out of int's range fail.
+func paramInt(s string) (int, error) {
+	s = strings.TrimLeft(s, " \t\n\v\f\r")
+	neg := false
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	var n int64
+	digits := 0
+	for ; digits < len(s) && s[digits] >= '0' && s[digits] <= '9'; digits++ {
+		if n <= math.MaxInt32+1 {
+			n = n*10 + int64(s[digits]-'0')
+		}
+	}
+	if neg {
+		n = -n
+	}
+	if digits == 0 || n > math.MaxInt32 || n < math.MinInt32 {
+		return 0, errors.New(MsgBadCast)
+	}
+	return int(n), nil
+}
+
+func first(params map[string][]string, key string) (string, bool) {
+	v, ok := params[key]
+	if !ok {
+		return "", false
+	}
+	if len(v) == 0 {
+		return "", true
+	}
+	return v[0], true
+}
+
+func prefix(s string, n int) string {
+	if len(s) < n {
+		return s
+	}
+	return s[:n]
+}
+
+// send writes a plain body without a Content-Type header, as Pistache's
+// response.send(code, body) does.
+func send(w http.ResponseWriter, status int, body string) {
+	w.Header()["Content-Type"] = nil
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	io.WriteString(w, body)
+}
+
+func sendJSON(w http.ResponseWriter, status int, v interface{}) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	send(w, status, strings.TrimSuffix(buf.String(), "\n"))
+}
@@ -0,0 +1,116 @@
+package refapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type exchange struct {
+	method, path, body string
+	status             int
+	want               string
+}
+
+func run(t *testing.T, steps []exchange) {
+	t.Helper()
+	srv, _ := NewServer()
+	defer srv.Close()
+	for i, s := range steps {
+		req, err := http.NewRequest(s.method, srv.URL+s.path, strings.NewReader(s.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != s.status || string(body) != s.want {
+			t.Fatalf("step %d %s %s: got %d %q, want %d %q", i, s.method, s.path, resp.StatusCode, body, s.status, s.want)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "" {
+			t.Fatalf("step %d: unexpected Content-Type %q", i, ct)
+		}
+	}
+}
+
+const john = `{"city":"","email":"","first_name":"John","id":1,"last_name":"","middle_name":"","phone":"5551234567","state":"","street":"","zip":""}`
+
+func TestCRUD(t *testing.T) {
+	run(t, []exchange{
+		{"POST", "/records", `{"first_name":"John","phone":"5551234567","nickname":"x","id":99}`, 201, john},
+		{"GET", "/records/1", "", 200, john},
+		{"GET", "/records/", "", 200, "[" + john + "]"},
+		{"PUT", "/records/1", `{"city":"Springfield"}`, 200, strings.Replace(john, `"city":""`, `"city":"Springfield"`, 1)},
+		{"DELETE", "/records/1", "", 204, ""},
+		{"GET", "/records/1", "", 404, MsgNotFound},
+		{"DELETE", "/records/1", "", 404, MsgNotFound},
+		{"PUT", "/records/1", `{}`, 404, MsgNotFound},
+	})
+}
+
+// Malformed JSON fails in json::parse, before next_id_++; bodies that parse
+// fail later and use up an ID.
+func TestTypeErrorsConsumeIDs(t *testing.T) {
+	run(t, []exchange{
+		{"POST", "/records", `not json`, 400, MsgInvalidJSON},
+		{"POST", "/records", `{"first_name":"John","phone":"5551234567"}`, 201, john},
+		{"POST", "/records", `{"first_name":5}`, 400, MsgInvalidJSON},
+		{"POST", "/records", `[]`, 400, MsgInvalidJSON},
+		{"POST", "/records", `{"first_name":"John","phone":"5551234567"}`, 201, strings.Replace(john, `"id":1`, `"id":4`, 1)},
+	})
+}
+
+func TestUpdateIsPartialOnTypeError(t *testing.T) {
+	run(t, []exchange{
+		{"POST", "/records", `{"first_name":"John","phone":"5551234567"}`, 201, john},
+		{"PUT", "/records/1", `{"first_name":"Jane","phone":null}`, 400, MsgInvalidJSON},
+		{"PUT", "/records/1", `garbage`, 400, MsgInvalidJSON},
+		{"GET", "/records/1", "", 200, strings.Replace(john, "John", "Jane", 1)},
+	})
+}
+
+func TestQuery(t *testing.T) {
+	run(t, []exchange{
+		{"POST", "/records", `{"first_name":"John","phone":"5551234567"}`, 201, john},
+		{"GET", "/records?phone=555", "", 200, "[" + john + "]"},
+		{"GET", "/records?phone=5551", "", 200, "[]"},
+		{"GET", "/records?first_name=john", "", 200, "[]"},
+		{"GET", "/records?id=1&nickname=x", "", 200, "[" + john + "]"},
+		{"GET", "/records?city=", "", 200, "[" + john + "]"},
+		{"DELETE", "/reset", "", 204, ""},
+		{"GET", "/records", "", 200, "[]"},
+		{"POST", "/records", `{"first_name":"John","phone":"5551234567"}`, 201, john},
+	})
+}
+
+func TestRouting(t *testing.T) {
+	run(t, []exchange{
+		{"PATCH", "/records/1", "", 404, MsgNoRoute},
+		{"GET", "/reset", "", 404, MsgNoRoute},
+		{"GET", "/records/abc", "", 500, MsgBadCast},
+		{"PUT", "/records/99999999999", `{}`, 500, MsgBadCast},
+		{"DELETE", "/records/12abc", "", 404, MsgNotFound},
+		{"GET", "/nothing", "", 404, MsgNoRoute},
+	})
+}
+
+func TestParamInt(t *testing.T) {
+	tests := map[string]int{
+		"42": 42, "-1": -1, "+7": 7, "12abc": 12, " 3": 3,
+		"2147483647": 2147483647, "-2147483648": -2147483648,
+	}
+	for in, want := range tests {
+		if got, err := paramInt(in); err != nil || got != want {
+			t.Errorf("paramInt(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"abc", "", "-", "+x", "2147483648", "99999999999", "-99999999999"} {
+		if got, err := paramInt(in); err == nil || err.Error() != MsgBadCast {
+			t.Errorf("paramInt(%q) = %d, %v, want %q", in, got, err, MsgBadCast)
+		}
+	}
+}
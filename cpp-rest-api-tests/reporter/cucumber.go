@@ -0,0 +1,99 @@
+package reporter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The subset of godog's cucumber JSON format the reports are built from.
+type cukeFeature struct {
+	URI      string        `json:"uri"`
+	Name     string        `json:"name"`
+	Elements []cukeElement `json:"elements"`
+}
+
+type cukeElement struct {
+	Name  string     `json:"name"`
+	Line  int        `json:"line"`
+	Type  string     `json:"type"`
+	Steps []cukeStep `json:"steps"`
+}
+
+type cukeStep struct {
+	Keyword string `json:"keyword"`
+	Name    string `json:"name"`
+	Result  struct {
+		Status   string `json:"status"`
+		Error    string `json:"error_message"`
+		Duration int64  `json:"duration"` // nanoseconds
+	} `json:"result"`
+	Embeddings []struct {
+		Name     string `json:"name"`
+		MimeType string `json:"mime_type"`
+		Data     string `json:"data"` // base64
+	} `json:"embeddings"`
+}
+
+// Parse reads a cucumber JSON report, as written by godog's cucumber
+// formatter, into a Run stamped with at.
+func Parse(r io.Reader, at time.Time) (*Run, error) {
+	var features []cukeFeature
+	if err := json.NewDecoder(r).Decode(&features); err != nil {
+		return nil, fmt.Errorf("failed to parse cucumber report: %v", err)
+	}
+	run := &Run{Time: at}
+	for _, f := range features {
+		for _, e := range f.Elements {
+			if e.Type != "" && e.Type != "scenario" {
+				continue
+			}
+			sc := Scenario{Feature: f.Name, Name: e.Name, URI: f.URI, Line: e.Line}
+			for _, cs := range e.Steps {
+				st := Step{
+					Keyword:  cs.Keyword,
+					Name:     cs.Name,
+					Status:   cs.Result.Status,
+					Error:    cs.Result.Error,
+					Duration: time.Duration(cs.Result.Duration),
+				}
+				for _, em := range cs.Embeddings {
+					if em.Name != TranscriptName {
+						continue
+					}
+					data, err := base64.StdEncoding.DecodeString(em.Data)
+					if err != nil {
+						return nil, fmt.Errorf("%s: bad transcript embedding: %v", sc.ID(), err)
+					}
+					st.Transcript = string(data)
+				}
+				sc.Duration += st.Duration
+				sc.Steps = append(sc.Steps, st)
+			}
+			sc.Status = scenarioStatus(sc.Steps)
+			run.Duration += sc.Duration
+			run.Scenarios = append(run.Scenarios, sc)
+		}
+	}
+	return run, nil
+}
+
+// scenarioStatus is failed when a step did not pass or get skipped after
+// a failure, skipped when any step was skipped, and passed otherwise. A
+// scenario that stopped at a skipped step, such as an @expected-fail one
+// that failed as expected, did not pass.
+func scenarioStatus(steps []Step) string {
+	status := Passed
+	for _, st := range steps {
+		switch st.Status {
+		case Passed:
+		case Skipped:
+			status = Skipped
+		default:
+			return Failed
+		}
+	}
+	return status
+}
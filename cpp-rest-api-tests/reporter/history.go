@@ -0,0 +1,104 @@
+package reporter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// DefaultKeep is how many runs a history holds unless told otherwise.
+const DefaultKeep = 100
+
+// History is the JSON history file: a summary of each past run, oldest
+// first.
+type History struct {
+	Runs []HistoryRun `json:"runs"`
+}
+
+type HistoryRun struct {
+	Time       time.Time `json:"time"`
+	DurationMS int64     `json:"duration_ms"`
+	Passed     int       `json:"passed"`
+	Failed     int       `json:"failed"`
+	Skipped    int       `json:"skipped"`
+	// Scenarios is keyed by Scenario.ID.
+	Scenarios map[string]HistoryScenario `json:"scenarios"`
+}
+
+type HistoryScenario struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// PassRate is the share of the run's scenarios that passed.
+func (r HistoryRun) PassRate() float64 {
+	total := r.Passed + r.Failed + r.Skipped
+	if total == 0 {
+		return 0
+	}
+	return float64(r.Passed) / float64(total)
+}
+
+// LoadHistory reads a history file. A missing file is an empty history.
+func LoadHistory(path string) (*History, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &History{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h History
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("failed to parse history %s: %v", path, err)
+	}
+	return &h, nil
+}
+
+func (h *History) Save(path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Add appends a summary of run and drops the oldest runs beyond keep.
+func (h *History) Add(run *Run, keep int) {
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+	entry := HistoryRun{
+		Time:       run.Time.UTC(),
+		DurationMS: run.Duration.Milliseconds(),
+		Passed:     run.Count(Passed),
+		Failed:     run.Count(Failed),
+		Skipped:    run.Count(Skipped),
+		Scenarios:  map[string]HistoryScenario{},
+	}
+	for _, sc := range run.Scenarios {
+		entry.Scenarios[sc.ID()] = HistoryScenario{Name: sc.Name, Status: sc.Status, DurationMS: sc.Duration.Milliseconds()}
+	}
+	h.Runs = append(h.Runs, entry)
+	if len(h.Runs) > keep {
+		h.Runs = h.Runs[len(h.Runs)-keep:]
+	}
+}
+
+// ScenarioTrend counts how often the scenario with id passed in the runs
+// it took part in.
+func (h *History) ScenarioTrend(id string) (passed, runs int) {
+	for _, r := range h.Runs {
+		if sc, ok := r.Scenarios[id]; ok {
+			runs++
+			if sc.Status == Passed {
+				passed++
+			}
+		}
+	}
+	return passed, runs
+}
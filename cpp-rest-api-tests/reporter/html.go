@@ -0,0 +1,168 @@
+package reporter
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// Trend chart geometry, in SVG user units.
+const (
+	chartHeight = 100
+	barWidth    = 12
+	barGap      = 3
+)
+
+type htmlBar struct {
+	X            int
+	PassY, PassH float64
+	FailY, FailH float64
+	SkipY, SkipH float64
+	Title        string
+}
+
+type htmlScenario struct {
+	Scenario
+	Trend string
+}
+
+type htmlFeature struct {
+	Name      string
+	Scenarios []htmlScenario
+}
+
+type htmlData struct {
+	Run      *Run
+	Passed   int
+	Failed   int
+	Skipped  int
+	Features []htmlFeature
+	Failures []Scenario
+	Bars     []htmlBar
+	Width    int
+	Duration string // polyline points
+}
+
+// WriteHTML writes a single page with no external assets: a summary, the
+// pass-rate and duration trend from history, every scenario with its pass
+// record, and the steps, errors and transcripts of the failed ones.
+func WriteHTML(w io.Writer, run *Run, history *History) error {
+	data := htmlData{
+		Run:     run,
+		Passed:  run.Count(Passed),
+		Failed:  run.Count(Failed),
+		Skipped: run.Count(Skipped),
+	}
+	index := map[string]int{}
+	for _, sc := range run.Scenarios {
+		i, ok := index[sc.Feature]
+		if !ok {
+			i = len(data.Features)
+			index[sc.Feature] = i
+			data.Features = append(data.Features, htmlFeature{Name: sc.Feature})
+		}
+		hs := htmlScenario{Scenario: sc}
+		if passed, runs := history.ScenarioTrend(sc.ID()); runs > 0 {
+			hs.Trend = fmt.Sprintf("%d/%d", passed, runs)
+		}
+		data.Features[i].Scenarios = append(data.Features[i].Scenarios, hs)
+		if sc.Status == Failed {
+			data.Failures = append(data.Failures, sc)
+		}
+	}
+	data.Bars, data.Duration, data.Width = trend(history)
+	return page.Execute(w, data)
+}
+
+// trend lays out one stacked bar per run, passed at the bottom, and a
+// line of run durations scaled to the slowest run.
+func trend(h *History) ([]htmlBar, string, int) {
+	var bars []htmlBar
+	var points []string
+	var slowest int64 = 1
+	for _, r := range h.Runs {
+		if r.DurationMS > slowest {
+			slowest = r.DurationMS
+		}
+	}
+	for i, r := range h.Runs {
+		total := float64(r.Passed + r.Failed + r.Skipped)
+		if total == 0 {
+			total = 1
+		}
+		b := htmlBar{X: i * (barWidth + barGap)}
+		b.PassH = chartHeight * float64(r.Passed) / total
+		b.FailH = chartHeight * float64(r.Failed) / total
+		b.SkipH = chartHeight * float64(r.Skipped) / total
+		b.PassY = chartHeight - b.PassH
+		b.FailY = b.PassY - b.FailH
+		b.SkipY = b.FailY - b.SkipH
+		b.Title = fmt.Sprintf("%s: %d passed, %d failed, %d skipped in %s",
+			r.Time.Local().Format("2006-01-02 15:04"), r.Passed, r.Failed, r.Skipped,
+			(time.Duration(r.DurationMS) * time.Millisecond).String())
+		bars = append(bars, b)
+		y := chartHeight - chartHeight*float64(r.DurationMS)/float64(slowest)
+		points = append(points, fmt.Sprintf("%d,%.1f", b.X+barWidth/2, y))
+	}
+	return bars, strings.Join(points, " "), len(h.Runs) * (barWidth + barGap)
+}
+
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ms":   func(d time.Duration) string { return d.Round(time.Millisecond).String() },
+	"when": func(t time.Time) string { return t.Local().Format("2006-01-02 15:04:05") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Contacts API BDD report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { text-align: left; padding: 0.2em 0.8em; border-bottom: 1px solid #ddd; }
+.passed { color: #1a7f37; } .failed { color: #cf222e; } .skipped { color: #9a6700; }
+pre { background: #f6f8fa; padding: 0.8em; overflow-x: auto; }
+.chart { border: 1px solid #ddd; margin-bottom: 0.3em; }
+</style>
+</head>
+<body>
+<h1>Contacts API BDD report</h1>
+<p>{{when .Run.Time}}: {{len .Run.Scenarios}} scenarios,
+<span class="passed">{{.Passed}} passed</span>,
+<span class="failed">{{.Failed}} failed</span>,
+<span class="skipped">{{.Skipped}} skipped</span> in {{ms .Run.Duration}}.</p>
+{{if .Bars}}
+<h2>Trend</h2>
+<svg class="chart" width="{{.Width}}" height="100" viewBox="0 0 {{.Width}} 100">
+{{range .Bars}}<g><title>{{.Title}}</title>
+<rect x="{{.X}}" y="{{.PassY}}" width="12" height="{{.PassH}}" fill="#2da44e"/>
+<rect x="{{.X}}" y="{{.FailY}}" width="12" height="{{.FailH}}" fill="#cf222e"/>
+<rect x="{{.X}}" y="{{.SkipY}}" width="12" height="{{.SkipH}}" fill="#d4a72c"/></g>
+{{end}}<polyline points="{{.Duration}}" fill="none" stroke="#0969da" stroke-width="1.5"/>
+</svg>
+<p>Bars: scenario results per run. Line: run duration, relative to the slowest run.</p>
+{{end}}
+<h2>Scenarios</h2>
+{{range .Features}}
+<h3>{{.Name}}</h3>
+<table>
+<tr><th>Scenario</th><th>Status</th><th>Duration</th><th>Passed in history</th></tr>
+{{range .Scenarios}}<tr><td>{{.Name}}</td><td class="{{.Status}}">{{.Status}}</td><td>{{ms .Duration}}</td><td>{{.Trend}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Failures}}
+<h2>Failures</h2>
+{{range .Failures}}
+<h3>{{.Feature}}: {{.Name}}</h3>
+<p>{{.URI}}:{{.Line}}</p>
+<table>
+{{range .Steps}}<tr><td class="{{.Status}}">{{.Status}}</td><td>{{.Keyword}}{{.Name}}</td></tr>
+{{if .Error}}<tr><td></td><td><pre>{{.Error}}</pre></td></tr>{{end}}
+{{if .Transcript}}<tr><td></td><td><details open><summary>Transcript</summary><pre>{{.Transcript}}</pre></details></td></tr>{{end}}
+{{end}}</table>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,130 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut *junitText    `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+type junitText struct {
+	Text string `xml:",cdata"`
+}
+
+// WriteJUnit writes the run as JUnit XML: a test suite per feature and a
+// test case per scenario. A failure names the failing step and carries its
+// error and transcript.
+func WriteJUnit(w io.Writer, run *Run) error {
+	out := junitSuites{Name: "godog", Time: seconds(run.Duration)}
+	index := map[string]int{}
+	for _, sc := range run.Scenarios {
+		i, ok := index[sc.Feature]
+		if !ok {
+			i = len(out.Suites)
+			index[sc.Feature] = i
+			out.Suites = append(out.Suites, junitSuite{Name: sc.Feature, Timestamp: run.Time.UTC().Format(time.RFC3339)})
+		}
+		suite := &out.Suites[i]
+		c := junitCase{Name: sc.Name, Classname: sc.Feature, Time: seconds(sc.Duration)}
+		switch sc.Status {
+		case Failed:
+			c.Failure = failure(sc)
+			suite.Failures++
+			out.Failures++
+		case Skipped:
+			c.Skipped = &struct{}{}
+			suite.Skipped++
+			out.Skipped++
+		}
+		c.SystemOut = &junitText{Text: steps(sc)}
+		suite.Cases = append(suite.Cases, c)
+		suite.Tests++
+		out.Tests++
+	}
+	for i := range out.Suites {
+		var d time.Duration
+		for _, sc := range run.Scenarios {
+			if sc.Feature == out.Suites[i].Name {
+				d += sc.Duration
+			}
+		}
+		out.Suites[i].Time = seconds(d)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func failure(sc Scenario) *junitFailure {
+	for _, st := range sc.Steps {
+		if st.Status == Passed || st.Status == Skipped {
+			continue
+		}
+		f := &junitFailure{
+			Message: fmt.Sprintf("%s%s: %s", st.Keyword, st.Name, st.Status),
+			Type:    st.Status,
+			Text:    st.Error,
+		}
+		if st.Transcript != "" {
+			f.Text += "\n\n" + st.Transcript
+		}
+		return f
+	}
+	return &junitFailure{Message: "failed", Type: Failed}
+}
+
+func steps(sc Scenario) string {
+	var b strings.Builder
+	for _, st := range sc.Steps {
+		fmt.Fprintf(&b, "%-9s %s%s\n", st.Status, st.Keyword, st.Name)
+	}
+	return b.String()
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,125 @@
+// Package reporter turns godog's cucumber JSON into reports that need no
+// Jenkins plugin: JUnit XML, a self-contained HTML page showing the
+// request/response transcript of every failed step, and a JSON history of
+// past runs for pass-rate and duration trends.
+//
+// Transcripts come from Capture: the step library records the exchanges of
+// each step and attaches them to the step when it fails.
+package reporter
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Scenario and step statuses.
+const (
+	Passed  = "passed"
+	Failed  = "failed"
+	Skipped = "skipped"
+)
+
+// TranscriptName is the file name transcripts are attached to steps under.
+const TranscriptName = "transcript.txt"
+
+// Run is one suite run.
+type Run struct {
+	Time      time.Time
+	Duration  time.Duration
+	Scenarios []Scenario
+}
+
+type Scenario struct {
+	Feature  string
+	Name     string
+	URI      string
+	Line     int
+	Status   string
+	Duration time.Duration
+	Steps    []Step
+}
+
+type Step struct {
+	Keyword    string
+	Name       string
+	Status     string // as godog reports it, e.g. undefined or ambiguous
+	Error      string
+	Duration   time.Duration
+	Transcript string
+}
+
+// ID identifies a scenario across runs. Names repeat in outlines; the
+// line of the example row does not.
+func (s Scenario) ID() string {
+	return fmt.Sprintf("%s:%d", s.URI, s.Line)
+}
+
+// Count returns how many scenarios ended with status.
+func (r *Run) Count(status string) int {
+	n := 0
+	for _, sc := range r.Scenarios {
+		if sc.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Files names the outputs Generate writes. Empty names are skipped.
+type Files struct {
+	JUnit   string
+	HTML    string
+	History string
+	// Keep is how many runs the history holds; 0 means DefaultKeep.
+	Keep int
+}
+
+// Generate reads a cucumber JSON report and writes the reports named in
+// files. The run is added to the history before the HTML is written, so
+// the trend includes it.
+func Generate(cucumberJSON string, files Files, at time.Time) (*Run, error) {
+	f, err := os.Open(cucumberJSON)
+	if err != nil {
+		return nil, err
+	}
+	run, err := Parse(f, at)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	history := &History{}
+	if files.History != "" {
+		if history, err = LoadHistory(files.History); err != nil {
+			return nil, err
+		}
+		history.Add(run, files.Keep)
+		if err := history.Save(files.History); err != nil {
+			return nil, err
+		}
+	}
+	if files.JUnit != "" {
+		if err := writeFile(files.JUnit, func(f *os.File) error { return WriteJUnit(f, run) }); err != nil {
+			return nil, err
+		}
+	}
+	if files.HTML != "" {
+		if err := writeFile(files.HTML, func(f *os.File) error { return WriteHTML(f, run, history) }); err != nil {
+			return nil, err
+		}
+	}
+	return run, nil
+}
+
+func writeFile(path string, write func(*os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return f.Close()
+}
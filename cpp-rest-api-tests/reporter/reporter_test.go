@@ -0,0 +1,200 @@
+package reporter
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var transcript = "> GET /records/1\n< 404 Not Found\n<\n< Record not found\n"
+
+// cucumber is a godog cucumber report with a passing scenario, a failing
+// one whose failed step carries a transcript, and a skipped one.
+var cucumber = fmt.Sprintf(`[
+  {"uri": "features/contacts.feature", "name": "Contacts", "elements": [
+    {"name": "Create", "line": 5, "type": "scenario", "steps": [
+      {"keyword": "Given ", "name": "the API is running", "result": {"status": "passed", "duration": 1000000}},
+      {"keyword": "Then ", "name": "the response status code should be 201", "result": {"status": "passed", "duration": 2000000}}
+    ]},
+    {"name": "Read <b>", "line": 9, "type": "scenario", "steps": [
+      {"keyword": "When ", "name": "I send a GET request to \"/records/1\"", "result": {"status": "passed", "duration": 3000000}},
+      {"keyword": "Then ", "name": "the response status code should be 200", "result": {"status": "failed", "error_message": "expected status 200, got 404", "duration": 1000000},
+       "embeddings": [{"name": %q, "mime_type": "text/plain", "data": %q}]},
+      {"keyword": "And ", "name": "the response should contain \"John\"", "result": {"status": "skipped"}}
+    ]}
+  ]},
+  {"uri": "features/other.feature", "name": "Other", "elements": [
+    {"name": "Later", "line": 3, "type": "scenario", "steps": [
+      {"keyword": "Given ", "name": "something", "result": {"status": "skipped"}}
+    ]}
+  ]}
+]`, TranscriptName, base64.StdEncoding.EncodeToString([]byte(transcript)))
+
+var at = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func parse(t *testing.T) *Run {
+	t.Helper()
+	run, err := Parse(strings.NewReader(cucumber), at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return run
+}
+
+func TestParse(t *testing.T) {
+	run := parse(t)
+	if len(run.Scenarios) != 3 || run.Count(Passed) != 1 || run.Count(Failed) != 1 || run.Count(Skipped) != 1 {
+		t.Fatalf("scenarios: %+v", run.Scenarios)
+	}
+	read := run.Scenarios[1]
+	if read.ID() != "features/contacts.feature:9" || read.Duration != 4*time.Millisecond {
+		t.Fatalf("read: %+v", read)
+	}
+	if read.Steps[1].Transcript != transcript {
+		t.Fatalf("transcript %q", read.Steps[1].Transcript)
+	}
+	if run.Duration != 7*time.Millisecond {
+		t.Fatalf("run duration %s", run.Duration)
+	}
+}
+
+// An @expected-fail scenario reports its failing step as skipped; the
+// scenario did not pass.
+func TestPartlySkippedScenarioIsSkipped(t *testing.T) {
+	report := `[{"uri": "features/persistence.feature", "name": "Persistence", "elements": [
+	  {"name": "Contacts survive a restart", "line": 18, "type": "scenario", "steps": [
+	    {"keyword": "Given ", "name": "contact {alice} exists with first name \"Alice\"", "result": {"status": "passed"}},
+	    {"keyword": "Then ", "name": "contact {alice} should still exist", "result": {"status": "skipped"}}
+	  ]}
+	]}]`
+	run, err := Parse(strings.NewReader(report), at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Count(Skipped) != 1 || run.Count(Passed) != 0 {
+		t.Fatalf("scenarios: %+v", run.Scenarios)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var b strings.Builder
+	if err := WriteJUnit(&b, parse(t)); err != nil {
+		t.Fatal(err)
+	}
+	var got junitSuites
+	if err := xml.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Fatalf("%v\n%s", err, b.String())
+	}
+	if got.Tests != 3 || got.Failures != 1 || got.Skipped != 1 || len(got.Suites) != 2 {
+		t.Fatalf("totals: %+v", got)
+	}
+	contacts := got.Suites[0]
+	if contacts.Name != "Contacts" || contacts.Tests != 2 || contacts.Time != "0.007" {
+		t.Fatalf("suite: %+v", contacts)
+	}
+	f := contacts.Cases[1].Failure
+	if f == nil || f.Message != "Then the response status code should be 200: failed" ||
+		!strings.Contains(f.Text, "got 404") || !strings.Contains(f.Text, "< Record not found") {
+		t.Fatalf("failure: %+v", f)
+	}
+	if got.Suites[1].Cases[0].Skipped == nil {
+		t.Fatal("skipped scenario not marked")
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	run := parse(t)
+	h := &History{}
+	h.Add(run, 0)
+	var b strings.Builder
+	if err := WriteHTML(&b, run, h); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	for _, want := range []string{
+		"3 scenarios",
+		"Read &lt;b&gt;",
+		"expected status 200, got 404",
+		"&gt; GET /records/1",
+		"<polyline",
+		"<td>1/1</td>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+	if strings.Contains(page, "<script") || strings.Contains(page, "http://") || strings.Contains(page, "https://") {
+		t.Error("page is not self-contained")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	in := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(in, []byte(cucumber), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := Generate(in, Files{History: path, Keep: 3}, at.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Runs) != 3 || !h.Runs[0].Time.Equal(at.Add(time.Hour)) {
+		t.Fatalf("runs: %+v", h.Runs)
+	}
+	r := h.Runs[2]
+	if r.Passed != 1 || r.Failed != 1 || r.Skipped != 1 || r.DurationMS != 7 {
+		t.Fatalf("last run: %+v", r)
+	}
+	if rate := r.PassRate(); rate < 0.33 || rate > 0.34 {
+		t.Fatalf("pass rate %v", rate)
+	}
+	if passed, runs := h.ScenarioTrend("features/contacts.feature:9"); passed != 0 || runs != 3 {
+		t.Fatalf("trend %d/%d", passed, runs)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":1}`)
+	}))
+	defer srv.Close()
+	c := Capture(srv.Client())
+
+	ctx, tr := WithTranscript(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "POST", srv.URL+"/records?x=1", strings.NewReader(`{"first_name":"Ann"}`))
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// Requests without a transcript are not recorded.
+	plain, _ := http.NewRequest("GET", srv.URL, nil)
+	if resp, err := c.Do(plain); err == nil {
+		resp.Body.Close()
+	}
+
+	got := tr.String()
+	for _, want := range []string{"> POST /records?x=1\n", `> {"first_name":"Ann"}`, "< 201 Created\n", `< {"id":1}`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("transcript does not contain %q:\n%s", want, got)
+		}
+	}
+	if tr.Len() != 1 {
+		t.Fatalf("recorded %d exchanges, want 1", tr.Len())
+	}
+}
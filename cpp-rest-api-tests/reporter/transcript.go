@@ -0,0 +1,135 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxBody caps how much of each body goes into a transcript.
+const maxBody = 4096
+
+// Transcript collects the HTTP exchanges made with one context, so that a
+// failed step can show what was sent and what came back.
+type Transcript struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+type transcriptKey struct{}
+
+// WithTranscript returns a context whose requests are recorded by a Capture
+// client, and the transcript they are recorded in.
+func WithTranscript(ctx context.Context) (context.Context, *Transcript) {
+	t := &Transcript{}
+	return context.WithValue(ctx, transcriptKey{}, t), t
+}
+
+func (t *Transcript) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.entries)
+}
+
+// String renders the exchanges in order, requests marked ">" and
+// responses "<".
+func (t *Transcript) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.Join(t.entries, "\n")
+}
+
+func (t *Transcript) add(entry string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = append(t.entries, entry)
+}
+
+// Capture returns a copy of c that records every exchange made with a
+// context from WithTranscript. Other requests pass through untouched.
+func Capture(c *http.Client) *http.Client {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	wrapped := *c
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped.Transport = &captureTransport{base: base}
+	return &wrapped
+}
+
+type captureTransport struct {
+	base http.RoundTripper
+}
+
+func (c *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t, _ := req.Context().Value(transcriptKey{}).(*Transcript)
+	if t == nil {
+		return c.base.RoundTrip(req)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "> %s %s\n", req.Method, req.URL.RequestURI())
+	writeHeaders(&b, "> ", req.Header)
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		writeBody(&b, "> ", body)
+	}
+
+	resp, err := c.base.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(&b, "< %v\n", err)
+		t.add(b.String())
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	fmt.Fprintf(&b, "< %s\n", resp.Status)
+	writeHeaders(&b, "< ", resp.Header)
+	writeBody(&b, "< ", body)
+	if err != nil {
+		fmt.Fprintf(&b, "< (reading the body failed: %v)\n", err)
+	}
+	t.add(b.String())
+	return resp, err
+}
+
+func writeHeaders(b *strings.Builder, prefix string, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range h[name] {
+			fmt.Fprintf(b, "%s%s: %s\n", prefix, name, v)
+		}
+	}
+}
+
+func writeBody(b *strings.Builder, prefix string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	text := string(body)
+	if len(text) > maxBody {
+		text = fmt.Sprintf("%s... (%d more bytes)", text[:maxBody], len(text)-maxBody)
+	}
+	b.WriteString(strings.TrimSpace(prefix) + "\n")
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(prefix + line + "\n")
+	}
+}
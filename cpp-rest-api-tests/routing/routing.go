@@ -0,0 +1,199 @@
+// Package routing sends every HTTP method to every known and unknown path
+// and compares the status codes with an expected table.
+//
+// main.cpp binds six routes. Everything else, including a known path with
+// an unbound method, is answered by the Pistache router with 404 "Could not
+// find a matching route". The table tells the two 404s apart: "404" is
+// ApiHandler's "Record not found", "-" is the router's.
+package routing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/refapi"
+)
+
+// Unrouted marks a request no route matched.
+const Unrouted = "-"
+
+var Methods = []string{"GET", "POST", "PUT", "DELETE", "PATCH", "HEAD", "OPTIONS"}
+
+// Paths are the known routes, their near misses and a few unknown paths.
+// Each request is sent to a store holding a single contact, ID 1.
+var Paths = []string{
+	"/records",
+	"/records/",
+	"//records",
+	"/records/1",
+	"/records/1/",
+	"/records/99",
+	"/records/0",
+	"/records/-1",
+	"/records/abc",
+	"/records/1/extra",
+	"/RECORDS",
+	"/reset",
+	"/reset/",
+	"/",
+	"/nothing",
+}
+
+// body is sent with the methods that can carry one.
+const body = `{"first_name":"Routed"}`
+
+// Table is a status matrix: one row per path, one column per method.
+type Table struct {
+	Methods []string
+	Rows    []Row
+}
+
+// Row is the statuses for one path. Measured is set once the row has been
+// compared with a running build of main.cpp. Until then the row is read
+// from main.cpp and Pistache's source, and a mismatch may be the row's
+// fault as much as the server's.
+type Row struct {
+	Path     string
+	Status   []string
+	Measured bool
+}
+
+// Get returns the cell for method and path.
+func (t *Table) Get(method, path string) (string, bool) {
+	col := -1
+	for i, m := range t.Methods {
+		if m == method {
+			col = i
+		}
+	}
+	if col < 0 {
+		return "", false
+	}
+	for _, row := range t.Rows {
+		if row.Path == path && col < len(row.Status) {
+			return row.Status[col], true
+		}
+	}
+	return "", false
+}
+
+// Paths lists the row paths in order.
+func (t *Table) Paths() []string {
+	paths := make([]string, len(t.Rows))
+	for i, row := range t.Rows {
+		paths[i] = row.Path
+	}
+	return paths
+}
+
+// Reference is what main.cpp answers, as far as reading main.cpp and
+// Pistache's source tells. No row has been measured against a build yet;
+// go test -v ./routing -run TestMatrix -args -routing.record prints the rows a
+// running server produces, ready to paste here. HEAD responses have no
+// body, so an unrouted HEAD cannot be told from a missing record and shows
+// as 404.
+var Reference = &Table{
+	Methods: Methods,
+	Rows: []Row{
+		// GET, POST, PUT, DELETE, PATCH, HEAD, OPTIONS; measured
+		{"/records", []string{"200", "201", "-", "-", "-", "404", "-"}, false},
+		{"/records/", []string{"200", "201", "-", "-", "-", "404", "-"}, false},
+		{"//records", []string{"200", "201", "-", "-", "-", "404", "-"}, false},
+		{"/records/1", []string{"200", "-", "200", "204", "-", "404", "-"}, false},
+		{"/records/1/", []string{"200", "-", "200", "204", "-", "404", "-"}, false},
+		{"/records/99", []string{"404", "-", "404", "404", "-", "404", "-"}, false},
+		{"/records/0", []string{"404", "-", "404", "404", "-", "404", "-"}, false},
+		{"/records/-1", []string{"404", "-", "404", "404", "-", "404", "-"}, false},
+		{"/records/abc", []string{"500", "-", "500", "500", "-", "404", "-"}, false},
+		{"/records/1/extra", []string{"-", "-", "-", "-", "-", "404", "-"}, false},
+		{"/RECORDS", []string{"-", "-", "-", "-", "-", "404", "-"}, false},
+		{"/reset", []string{"-", "-", "-", "204", "-", "404", "-"}, false},
+		{"/reset/", []string{"-", "-", "-", "204", "-", "404", "-"}, false},
+		{"/", []string{"-", "-", "-", "-", "-", "404", "-"}, false},
+		{"/nothing", []string{"-", "-", "-", "-", "-", "404", "-"}, false},
+	},
+}
+
+// Run sends every method to every path, each against a store reset to
+// hold one contact, and returns what came back. The store is reset again
+// at the end.
+func Run(ctx context.Context, api *client.Client, methods, paths []string) (*Table, error) {
+	got := &Table{Methods: methods}
+	for _, path := range paths {
+		row := Row{Path: path}
+		for _, method := range methods {
+			status, err := send(ctx, api, method, path)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %v", method, path, err)
+			}
+			row.Status = append(row.Status, status)
+		}
+		got.Rows = append(got.Rows, row)
+	}
+	if err := api.Reset(ctx); err != nil {
+		return nil, err
+	}
+	return got, nil
+}
+
+func send(ctx context.Context, api *client.Client, method, path string) (string, error) {
+	if err := api.Reset(ctx); err != nil {
+		return "", err
+	}
+	if _, err := api.Create(ctx, client.Contact{FirstName: "Routed", Phone: "5550001111"}); err != nil {
+		return "", err
+	}
+	var data []byte
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		data = []byte(body)
+	}
+	resp, err := api.Do(ctx, method, path, data)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusNotFound && string(resp.Body) == refapi.MsgNoRoute {
+		return Unrouted, nil
+	}
+	return strconv.Itoa(resp.StatusCode), nil
+}
+
+// GoString renders t's rows as Go literals for Reference, marked measured.
+func (t *Table) GoString() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\t\t// %s; measured\n", strings.Join(t.Methods, ", "))
+	for _, row := range t.Rows {
+		fmt.Fprintf(&b, "\t\t{%q, []string{%q", row.Path, row.Status[0])
+		for _, st := range row.Status[1:] {
+			fmt.Fprintf(&b, ", %q", st)
+		}
+		b.WriteString("}, true},\n")
+	}
+	return b.String()
+}
+
+// Diff lists every cell of want that got disagrees with.
+func Diff(want, got *Table) []string {
+	var out []string
+	for _, row := range want.Rows {
+		for i, method := range want.Methods {
+			g, ok := got.Get(method, row.Path)
+			if !ok {
+				out = append(out, fmt.Sprintf("%s %s: not sent", method, row.Path))
+				continue
+			}
+			if g != row.Status[i] {
+				d := fmt.Sprintf("%s %s: expected %s, got %s", method, row.Path, row.Status[i], g)
+				if !row.Measured {
+					d += " (row not measured against main.cpp)"
+				}
+				out = append(out, d)
+			}
+		}
+	}
+	return out
+}
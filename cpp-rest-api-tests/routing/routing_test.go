@@ -0,0 +1,76 @@
+package routing
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/refapi"
+	"cpp-rest-api-tests/testenv"
+)
+
+var (
+	targetFlags = config.BindFlags(flag.CommandLine)
+	record      = flag.Bool("routing.record", false, "print the measured matrix as rows for Reference")
+)
+
+var api *client.Client
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { api = env.API })
+}
+
+func TestMatrix(t *testing.T) {
+	got, err := Run(context.Background(), api, Reference.Methods, Reference.Paths())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *record {
+		fmt.Print(got.GoString())
+	}
+	for _, d := range Diff(Reference, got) {
+		t.Error(d)
+	}
+}
+
+// A router that answers 405 for unbound methods, as it arguably should,
+// shows up cell by cell.
+func TestDiffReportsEveryCell(t *testing.T) {
+	ref := refapi.NewHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/records/") {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		ref.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	paths := []string{"/records/1", "/records/abc", "/nothing"}
+	got, err := Run(context.Background(), client.New(srv.URL, nil), Methods, paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Table{Methods: Methods}
+	for _, row := range Reference.Rows {
+		for _, p := range paths {
+			if row.Path == p {
+				want.Rows = append(want.Rows, row)
+			}
+		}
+	}
+	diff := Diff(want, got)
+	wantDiff := []string{
+		"PATCH /records/1: expected -, got 405 (row not measured against main.cpp)",
+		"PATCH /records/abc: expected -, got 405 (row not measured against main.cpp)",
+	}
+	if strings.Join(diff, "\n") != strings.Join(wantDiff, "\n") {
+		t.Fatalf("diff:\n%s\nwant:\n%s", strings.Join(diff, "\n"), strings.Join(wantDiff, "\n"))
+	}
+}
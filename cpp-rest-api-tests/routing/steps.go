@@ -0,0 +1,77 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/scenario"
+)
+
+// Steps exposes the routing matrix to godog scenarios.
+type Steps struct {
+	api *client.Client
+}
+
+func NewSteps(api *client.Client) *Steps {
+	return &Steps{api: api}
+}
+
+func (s *Steps) Register(ctx scenario.Context) {
+	ctx.Step(`^every method sent to every path should return:$`, s.everyMethodSentToEveryPathShouldReturn)
+	ctx.Step(`^the routing matrix should match the reference$`, s.theRoutingMatrixShouldMatchTheReference)
+}
+
+// everyMethodSentToEveryPathShouldReturn reads a table whose header names
+// the methods after a "path" column, and whose rows give the expected
+// status for each path, "-" meaning no route matched.
+func (s *Steps) everyMethodSentToEveryPathShouldReturn(table *godog.Table) error {
+	want, err := parseTable(table)
+	if err != nil {
+		return err
+	}
+	return s.compare(want)
+}
+
+func (s *Steps) theRoutingMatrixShouldMatchTheReference() error {
+	return s.compare(Reference)
+}
+
+func (s *Steps) compare(want *Table) error {
+	got, err := Run(context.Background(), s.api, want.Methods, want.Paths())
+	if err != nil {
+		return err
+	}
+	if diff := Diff(want, got); len(diff) > 0 {
+		return fmt.Errorf("%d routing mismatch(es):\n  %s", len(diff), strings.Join(diff, "\n  "))
+	}
+	return nil
+}
+
+func parseTable(table *godog.Table) (*Table, error) {
+	if len(table.Rows) < 2 {
+		return nil, fmt.Errorf("expected a header row and at least one path")
+	}
+	header := table.Rows[0].Cells
+	if header[0].Value != "path" {
+		return nil, fmt.Errorf("the first column must be \"path\", got %q", header[0].Value)
+	}
+	t := &Table{}
+	for _, cell := range header[1:] {
+		t.Methods = append(t.Methods, strings.ToUpper(cell.Value))
+	}
+	for i, row := range table.Rows[1:] {
+		if len(row.Cells) != len(header) {
+			return nil, fmt.Errorf("row %d has %d cells, the header has %d", i+1, len(row.Cells), len(header))
+		}
+		r := Row{Path: row.Cells[0].Value}
+		for _, cell := range row.Cells[1:] {
+			r.Status = append(r.Status, cell.Value)
+		}
+		t.Rows = append(t.Rows, r)
+	}
+	return t, nil
+}
@@ -0,0 +1,14 @@
+// Package scenario is what the step packages register their steps on.
+//
+// step_definitions passes them a *godog.ScenarioContext wrapped so that a
+// scenario's tags, such as @expected-fail, apply to every step whichever
+// package defines it. A plain *godog.ScenarioContext works as well.
+package scenario
+
+import "github.com/cucumber/godog"
+
+// Context is the part of *godog.ScenarioContext the step packages use.
+type Context interface {
+	Step(expr, stepFunc interface{})
+	After(godog.AfterScenarioHook)
+}
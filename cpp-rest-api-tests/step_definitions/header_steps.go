@@ -0,0 +1,79 @@
+package step_definitions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+func (c *ContactTest) iSetTheRequestHeaderTo(ctx context.Context, name, value string) error {
+	value, err := c.expand(value)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("use `I send requests without a %q header` to leave it out", name)
+	}
+	c.setHeader(name, value)
+	return nil
+}
+
+// iSendRequestsWithoutAHeader drops a header, including the Content-Type
+// the client adds to every request with a body.
+func (c *ContactTest) iSendRequestsWithoutAHeader(ctx context.Context, name string) error {
+	c.setHeader(name, "")
+	return nil
+}
+
+func (c *ContactTest) setHeader(name, value string) {
+	if c.headers == nil {
+		c.headers = make(map[string]string)
+	}
+	c.headers[http.CanonicalHeaderKey(name)] = value
+}
+
+func (c *ContactTest) theResponseHeaderShouldBe(ctx context.Context, name, want string) error {
+	resp, err := c.response()
+	if err != nil {
+		return err
+	}
+	want, err = c.expand(want)
+	if err != nil {
+		return err
+	}
+	values, ok := resp.Header[http.CanonicalHeaderKey(name)]
+	if !ok {
+		return fmt.Errorf("response has no %s header", name)
+	}
+	if got := resp.Header.Get(name); got != want {
+		return fmt.Errorf("response header %s: expected %q, got %q", name, want, values)
+	}
+	return nil
+}
+
+func (c *ContactTest) theResponseShouldNotHaveAHeader(ctx context.Context, name string) error {
+	resp, err := c.response()
+	if err != nil {
+		return err
+	}
+	if values, ok := resp.Header[http.CanonicalHeaderKey(name)]; ok {
+		return fmt.Errorf("response has a %s header: %q", name, values)
+	}
+	return nil
+}
+
+func (c *ContactTest) theResponseContentLengthShouldMatchTheBody(ctx context.Context) error {
+	resp, err := c.response()
+	if err != nil {
+		return err
+	}
+	header := resp.Header.Get("Content-Length")
+	if header == "" {
+		return fmt.Errorf("response has no Content-Length header")
+	}
+	if n, err := strconv.Atoi(header); err != nil || n != len(resp.Body) {
+		return fmt.Errorf("Content-Length %q, body has %d bytes", header, len(resp.Body))
+	}
+	return nil
+}
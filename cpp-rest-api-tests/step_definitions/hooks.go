@@ -0,0 +1,209 @@
+package step_definitions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/cucumber/godog"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/reporter"
+)
+
+// isolation installs the Before/After hooks selected by config.Isolation.
+//
+// The hooks reset the one store the API has, so they only isolate
+// scenarios that run one at a time: a reset would wipe the records of a
+// scenario running alongside. Suites must keep godog's Concurrency at 1
+// unless isolation is "none"; a scenario that starts while another is
+// still running fails.
+type isolation struct {
+	level string
+	api   *client.Client
+
+	// cleaned is set once an After hook has reset the store. From then on
+	// the store must be empty when a scenario starts; anything else was
+	// written outside a scenario and would leak into the next one.
+	cleaned atomic.Bool
+
+	// running counts the scenarios between their Before and After hooks.
+	running atomic.Int32
+}
+
+func (iso *isolation) install(ctx *godog.ScenarioContext) {
+	if iso.level == config.IsolationNone {
+		return
+	}
+
+	ctx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+		if n := iso.running.Add(1); n > 1 {
+			return ctx, fmt.Errorf("%q started while %d other scenario(s) were running; %s isolation resets the shared store, so run with concurrency 1 or set %s=%s", sc.Name, n-1, iso.level, config.EnvIsolate, config.IsolationNone)
+		}
+		if iso.level == config.IsolationReset && iso.cleaned.Load() {
+			if err := expectEmpty(ctx, iso.api); err != nil {
+				return ctx, fmt.Errorf("leftover records before %q: %v", sc.Name, err)
+			}
+		}
+		if err := resetAndVerify(ctx, iso.api); err != nil {
+			return ctx, fmt.Errorf("before %q: %v", sc.Name, err)
+		}
+		return ctx, nil
+	})
+
+	ctx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		defer iso.running.Add(-1)
+		if iso.level != config.IsolationReset {
+			return ctx, nil
+		}
+		if rerr := resetAndVerify(ctx, iso.api); rerr != nil {
+			return ctx, fmt.Errorf("after %q: %v", sc.Name, rerr)
+		}
+		iso.cleaned.Store(true)
+		return ctx, nil
+	})
+}
+
+// resetAndVerify calls DELETE /reset and checks that the store is empty.
+func resetAndVerify(ctx context.Context, api *client.Client) error {
+	if err := api.Reset(ctx); err != nil {
+		return fmt.Errorf("reset failed: %v", err)
+	}
+	if err := expectEmpty(ctx, api); err != nil {
+		return fmt.Errorf("store not empty after reset: %v", err)
+	}
+	return nil
+}
+
+func expectEmpty(ctx context.Context, api *client.Client) error {
+	contacts, err := api.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(contacts) == 0 {
+		return nil
+	}
+	ids := make([]int, len(contacts))
+	for i, c := range contacts {
+		ids[i] = c.ID
+	}
+	return fmt.Errorf("%d records present (IDs %v)", len(contacts), ids)
+}
+
+// installTranscripts attaches the scenario's HTTP exchanges so far to a
+// failed step. The assertion that fails seldom makes the request it judges,
+// so the whole scenario is shown; the cucumber report and the reports built
+// from it carry the transcript.
+func installTranscripts(ctx *godog.ScenarioContext) {
+	ctx.StepContext().After(func(ctx context.Context, st *godog.Step, status godog.StepResultStatus, err error) (context.Context, error) {
+		// A failed Before hook can leave the scenario without state.
+		c, _ := ctx.Value(scenarioKey{}).(*ContactTest)
+		if status != godog.StepFailed || c == nil || c.transcript.Len() == 0 {
+			return ctx, nil
+		}
+		t := c.transcript
+		return godog.Attach(ctx, godog.Attachment{
+			Body:      []byte(t.String()),
+			FileName:  reporter.TranscriptName,
+			MediaType: "text/plain",
+		}), nil
+	})
+}
+
+// ExpectedFailTag marks a scenario that documents a known gap. Its first
+// failing step is reported as skipped, and the scenario fails if every
+// step passes, so the tag is removed once the gap is closed.
+const ExpectedFailTag = "@expected-fail"
+
+// StressTag marks scenarios that hammer the API from many clients at once.
+// main.cpp shares its records and ID counter across threads without
+// locking, so they would fail every run; DefaultTags leaves them out unless
+// they are asked for.
+const StressTag = "@stress"
+
+// DefaultTags is the tag expression the suites run with by default.
+const DefaultTags = "~" + StressTag
+
+// outcome applies ExpectedFailTag to the result of a step. A step that
+// skips itself, e.g. a restart against an API the suite did not launch,
+// proved nothing and stays skipped.
+func (c *ContactTest) outcome(err error) error {
+	if err == nil || !c.expectFail {
+		return err
+	}
+	if errors.Is(err, godog.ErrSkip) {
+		c.skipped = true
+		return err
+	}
+	c.failedAsExpected = true
+	return fmt.Errorf("%w: expected failure: %v", godog.ErrSkip, err)
+}
+
+func installExpectedFailures(ctx *godog.ScenarioContext) {
+	ctx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		c, _ := ctx.Value(scenarioKey{}).(*ContactTest)
+		if c == nil || !c.expectFail || c.failedAsExpected || c.skipped || err != nil {
+			return ctx, nil
+		}
+		return ctx, fmt.Errorf("%q is tagged %s but passed; remove the tag", sc.Name, ExpectedFailTag)
+	})
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// taggedContext registers every step through expectable, so that
+// ExpectedFailTag applies to the steps of the other packages too. godog's
+// step hooks cannot turn a failure into a skip, so the handlers themselves
+// are wrapped.
+type taggedContext struct {
+	*godog.ScenarioContext
+}
+
+func (t taggedContext) Step(expr, stepFunc interface{}) {
+	t.ScenarioContext.Step(expr, expectable(stepFunc))
+}
+
+// expectable wraps a step handler returning an error so that the error
+// goes through ContactTest.outcome. The wrapper takes the step's
+// context.Context first, adding it when the handler does not.
+func expectable(stepFunc interface{}) interface{} {
+	fv := reflect.ValueOf(stepFunc)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.IsVariadic() || ft.NumOut() == 0 || ft.Out(ft.NumOut()-1) != errorType {
+		return stepFunc
+	}
+	hasCtx := ft.NumIn() > 0 && ft.In(0) == contextType
+	in := []reflect.Type{contextType}
+	for i := 0; i < ft.NumIn(); i++ {
+		if i > 0 || !hasCtx {
+			in = append(in, ft.In(i))
+		}
+	}
+	out := make([]reflect.Type, ft.NumOut())
+	for i := range out {
+		out[i] = ft.Out(i)
+	}
+	last := len(out) - 1
+	return reflect.MakeFunc(reflect.FuncOf(in, out, false), func(args []reflect.Value) []reflect.Value {
+		ctx := args[0].Interface().(context.Context)
+		if !hasCtx {
+			args = args[1:]
+		}
+		results := fv.Call(args)
+		c, _ := ctx.Value(scenarioKey{}).(*ContactTest)
+		if c == nil {
+			return results
+		}
+		err, _ := results[last].Interface().(error)
+		if err = c.outcome(err); err != nil {
+			results[last] = reflect.ValueOf(&err).Elem()
+		}
+		return results
+	}).Interface()
+}
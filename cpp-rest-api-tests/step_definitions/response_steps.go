@@ -0,0 +1,272 @@
+package step_definitions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/jsondiff"
+	"cpp-rest-api-tests/jsonpath"
+)
+
+// response returns the last response, or an error if no request was sent.
+func (c *ContactTest) response() (*client.Response, error) {
+	if c.lastResponse == nil {
+		return nil, fmt.Errorf("no response received")
+	}
+	return c.lastResponse, nil
+}
+
+func (c *ContactTest) theResponseStatusCodeShouldBe(ctx context.Context, status int) error {
+	resp, err := c.response()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != status {
+		return fmt.Errorf("expected status %d, got %d, response body: %s", status, resp.StatusCode, resp.Body)
+	}
+	return nil
+}
+
+func (c *ContactTest) theResponseShouldContain(ctx context.Context, text string) error {
+	resp, err := c.response()
+	if err != nil {
+		return err
+	}
+	text, err = c.expand(text)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(string(resp.Body), text) {
+		return fmt.Errorf("expected response to contain %q, got:\n%s", text, resp.Body)
+	}
+	return nil
+}
+
+func (c *ContactTest) theResponseShouldContainContacts(ctx context.Context, count int) error {
+	var contacts []client.Contact
+	if err := c.decode(&contacts); err != nil {
+		return err
+	}
+	if len(contacts) != count {
+		return fmt.Errorf("expected %d contacts, got %d", count, len(contacts))
+	}
+	return nil
+}
+
+func (c *ContactTest) theResponseShouldContainTheContactID(ctx context.Context) error {
+	var body map[string]interface{}
+	if err := c.decode(&body); err != nil {
+		return err
+	}
+	if _, ok := body["id"]; !ok {
+		return fmt.Errorf("response does not contain an ID, body: %s", c.lastResponse.Body)
+	}
+	return nil
+}
+
+func (c *ContactTest) theResponseShouldContainTheContactDetails(ctx context.Context, docString *godog.DocString) error {
+	var expected, actual map[string]interface{}
+	if err := c.expect(docString, &expected); err != nil {
+		return err
+	}
+	if err := c.decode(&actual); err != nil {
+		return err
+	}
+	return jsondiff.Compare(actual, expected)
+}
+
+func (c *ContactTest) theResponseShouldContainAListWithTheContact(ctx context.Context, docString *godog.DocString) error {
+	var expected, actual []map[string]interface{}
+	if err := c.expect(docString, &expected); err != nil {
+		return err
+	}
+	if err := c.decode(&actual); err != nil {
+		return err
+	}
+	if len(actual) != len(expected) {
+		return fmt.Errorf("expected %d contact(s), got %d, response body: %s", len(expected), len(actual), c.lastResponse.Body)
+	}
+	return jsondiff.Compare(actual, expected)
+}
+
+func (c *ContactTest) theResponseFieldShouldEqual(ctx context.Context, path, want string) error {
+	got, err := c.field(path)
+	if err != nil {
+		return err
+	}
+	want, err = c.expand(want)
+	if err != nil {
+		return err
+	}
+	if text := jsonpath.Text(got); text != want {
+		return fmt.Errorf("response field %q: expected %q, got %q", path, want, text)
+	}
+	return nil
+}
+
+// theResponseFieldShouldEqualJSON compares a field with a JSON DocString,
+// so objects, arrays and numbers can be checked by value.
+func (c *ContactTest) theResponseFieldShouldEqualJSON(ctx context.Context, path string, docString *godog.DocString) error {
+	got, err := c.field(path)
+	if err != nil {
+		return err
+	}
+	var want interface{}
+	if err := c.expect(docString, &want); err != nil {
+		return err
+	}
+	if err := jsondiff.Compare(got, want); err != nil {
+		return fmt.Errorf("response field %q: %v", path, err)
+	}
+	return nil
+}
+
+func (c *ContactTest) everyContactInTheResponseShouldHave(ctx context.Context, path, want string) error {
+	var contacts []interface{}
+	if err := c.decode(&contacts); err != nil {
+		return err
+	}
+	if len(contacts) == 0 {
+		return fmt.Errorf("the response has no contacts")
+	}
+	want, err := c.expand(want)
+	if err != nil {
+		return err
+	}
+	var bad []string
+	for i, contact := range contacts {
+		got, err := jsonpath.Get(contact, path)
+		if err != nil {
+			bad = append(bad, fmt.Sprintf("[%d]: %v", i, err))
+		} else if text := jsonpath.Text(got); text != want {
+			bad = append(bad, fmt.Sprintf("[%d] (id %s): %q", i, idOf(contact), text))
+		}
+	}
+	if len(bad) > 0 {
+		return fmt.Errorf("%d of %d contacts do not have %q equal to %q:\n  %s", len(bad), len(contacts), path, want, strings.Join(bad, "\n  "))
+	}
+	return nil
+}
+
+// theResponseShouldMatchTheTable checks the response row by row. The header
+// names the fields (or paths) to compare; other fields are ignored. A single
+// object is treated as a one-row listing.
+func (c *ContactTest) theResponseShouldMatchTheTable(ctx context.Context, table *godog.Table) error {
+	if table == nil || len(table.Rows) == 0 {
+		return fmt.Errorf("step needs a table with a header row")
+	}
+	var doc interface{}
+	if err := c.decode(&doc); err != nil {
+		return err
+	}
+	rows, ok := doc.([]interface{})
+	if !ok {
+		rows = []interface{}{doc}
+	}
+
+	header := table.Rows[0].Cells
+	expected := make([]map[string]string, 0, len(table.Rows)-1)
+	for n, row := range table.Rows[1:] {
+		if len(row.Cells) != len(header) {
+			return fmt.Errorf("table row %d has %d cells, header has %d", n+1, len(row.Cells), len(header))
+		}
+		m := make(map[string]string, len(header))
+		for i, cell := range row.Cells {
+			v, err := c.expand(cell.Value)
+			if err != nil {
+				return err
+			}
+			m[header[i].Value] = v
+		}
+		expected = append(expected, m)
+	}
+	actual := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		m := make(map[string]string, len(header))
+		for _, col := range header {
+			if v, err := jsonpath.Get(row, col.Value); err != nil {
+				m[col.Value] = "(missing)"
+			} else {
+				m[col.Value] = jsonpath.Text(v)
+			}
+		}
+		actual = append(actual, m)
+	}
+
+	if err := jsondiff.Compare(actual, expected); err != nil {
+		if len(actual) != len(expected) {
+			return fmt.Errorf("expected %d rows, got %d; %v", len(expected), len(actual), err)
+		}
+		return err
+	}
+	return nil
+}
+
+// aSubsequentGETRequestToShouldReturn checks a status without replacing
+// the last response, so later steps still see the original one.
+func (c *ContactTest) aSubsequentGETRequestToShouldReturn(ctx context.Context, path string, status int) error {
+	path, err := c.expand(path)
+	if err != nil {
+		return err
+	}
+	resp, err := c.api.Do(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != status {
+		return fmt.Errorf("expected status %d, got %d, response body: %s", status, resp.StatusCode, resp.Body)
+	}
+	return nil
+}
+
+// decode unmarshals the last response body into v.
+func (c *ContactTest) decode(v interface{}) error {
+	resp, err := c.response()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(resp.Body, v); err != nil {
+		return fmt.Errorf("invalid JSON in response: %v, body: %s", err, resp.Body)
+	}
+	return nil
+}
+
+// field returns the value at a JSONPath-style path in the last response.
+func (c *ContactTest) field(path string) (interface{}, error) {
+	var doc interface{}
+	if err := c.decode(&doc); err != nil {
+		return nil, err
+	}
+	path, err := c.expand(path)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpath.Get(doc, path)
+}
+
+func idOf(contact interface{}) string {
+	if id, err := jsonpath.Get(contact, "id"); err == nil {
+		return jsonpath.Text(id)
+	}
+	return "?"
+}
+
+// expect unmarshals an expected body from a DocString after expansion.
+func (c *ContactTest) expect(docString *godog.DocString, v interface{}) error {
+	if docString == nil {
+		return fmt.Errorf("step needs a DocString with the expected JSON")
+	}
+	content, err := c.expand(docString.Content)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(content), v); err != nil {
+		return fmt.Errorf("invalid expected JSON: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package step_definitions
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/cucumber/godog"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/jsondiff"
+)
+
+func (c *ContactTest) contactExistsWithFirstName(ctx context.Context, name, firstName string) error {
+	if err := c.create(ctx, fullContact(firstName, "5550000000")); err != nil {
+		return err
+	}
+	return c.set(name, strconv.Itoa(c.lastID))
+}
+
+// theAPIIsRestarted notes the store and the highest ID issued so far, then
+// restarts the API through the suite's managed process. Against an API the
+// suite did not launch the scenario is skipped, not failed.
+func (c *ContactTest) theAPIIsRestarted(ctx context.Context) error {
+	if c.cfg.Launch == config.LaunchNone {
+		return fmt.Errorf("%w: cannot restart an API the suite did not launch; use -contacts.profile=managed or reference", godog.ErrSkip)
+	}
+	if c.restarter == nil {
+		return fmt.Errorf("the suite launched the API (%s) but gave the steps no way to restart it", c.cfg.Launch)
+	}
+	contacts, err := c.api.List(ctx)
+	if err != nil {
+		return err
+	}
+	c.beforeRestart = contacts
+	for _, contact := range append(contacts, c.contacts...) {
+		if contact.ID > c.highestID {
+			c.highestID = contact.ID
+		}
+	}
+	if err := c.restarter.Restart(); err != nil {
+		return fmt.Errorf("failed to restart the API: %v", err)
+	}
+	return c.theAPIIsRunning(ctx)
+}
+
+func (c *ContactTest) restarted() error {
+	if c.beforeRestart == nil {
+		return fmt.Errorf("the API has not been restarted in this scenario")
+	}
+	return nil
+}
+
+func (c *ContactTest) contactShouldStillExist(ctx context.Context, ref string) error {
+	if err := c.restarted(); err != nil {
+		return err
+	}
+	ref, err := c.expand(ref)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(ref)
+	if err != nil {
+		return fmt.Errorf("contact %q is not an ID", ref)
+	}
+	got, err := c.api.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("contact %d did not survive the restart: %v", id, err)
+	}
+	for _, want := range c.beforeRestart {
+		if want.ID == id {
+			return jsondiff.Compare(got, want)
+		}
+	}
+	return nil
+}
+
+func (c *ContactTest) everyContactShouldSurviveTheRestart(ctx context.Context) error {
+	if err := c.restarted(); err != nil {
+		return err
+	}
+	got, err := c.api.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(got) != len(c.beforeRestart) {
+		return fmt.Errorf("%d contacts before the restart, %d after", len(c.beforeRestart), len(got))
+	}
+	return jsondiff.Compare(got, c.beforeRestart)
+}
+
+// aNewContactShouldNotReuseAnID checks next_id against every ID issued
+// before the restart, including those of contacts deleted since.
+func (c *ContactTest) aNewContactShouldNotReuseAnID(ctx context.Context) error {
+	if err := c.restarted(); err != nil {
+		return err
+	}
+	created, err := c.api.Create(ctx, client.Contact{FirstName: "After", LastName: "Restart"})
+	if err != nil {
+		return err
+	}
+	c.contacts = append(c.contacts, created)
+	if created.ID <= c.highestID {
+		return fmt.Errorf("new contact got ID %d, but IDs up to %d were issued before the restart", created.ID, c.highestID)
+	}
+	return nil
+}
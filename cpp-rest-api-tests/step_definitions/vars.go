@@ -0,0 +1,114 @@
+package step_definitions
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	mrand "math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cpp-rest-api-tests/jsonpath"
+)
+
+// placeholder matches {name} and {name:arg}. Names start with a letter or
+// underscore, so JSON such as {"id": 1} is left alone.
+var placeholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_.]*)(?::([^{}\s"]*))?\}`)
+
+var varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// builtins generate a fresh value on every use. Save one with
+// `I set the variable "x" to "{random.email}"` to refer to it again.
+var builtins = map[string]func(arg string) (string, error){
+	"uuid": func(string) (string, error) { return newUUID(), nil },
+	"random.email": func(string) (string, error) {
+		return fmt.Sprintf("user-%s@example.com", newUUID()[:8]), nil
+	},
+	"random.phone": func(area string) (string, error) {
+		if area == "" {
+			area = strconv.Itoa(200 + mrand.IntN(800))
+		}
+		if len(area) != 3 || strings.Trim(area, "0123456789") != "" {
+			return "", fmt.Errorf("area code must be 3 digits, got %q", area)
+		}
+		return fmt.Sprintf("%s%07d", area, mrand.IntN(10000000)), nil
+	},
+	"random.name": func(prefix string) (string, error) {
+		if prefix == "" {
+			prefix = "Contact"
+		}
+		return prefix + "-" + newUUID(), nil
+	},
+}
+
+// expand fills in {lastCreatedID}, saved variables and built-ins in paths,
+// request bodies and expected values. An unknown name is an error rather
+// than text sent to the server.
+func (c *ContactTest) expand(s string) (string, error) {
+	var errs []string
+	out := placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		sub := placeholder.FindStringSubmatch(m)
+		v, err := c.lookup(sub[1], sub[2])
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", m, err))
+			return m
+		}
+		return v
+	})
+	if len(errs) > 0 {
+		return "", fmt.Errorf("cannot expand %s", strings.Join(errs, "; "))
+	}
+	return out, nil
+}
+
+func (c *ContactTest) lookup(name, arg string) (string, error) {
+	if v, ok := c.vars[name]; ok && arg == "" {
+		return v, nil
+	}
+	if name == "lastCreatedID" && arg == "" {
+		return strconv.Itoa(c.lastID), nil
+	}
+	if gen, ok := builtins[name]; ok {
+		return gen(arg)
+	}
+	return "", fmt.Errorf("undefined variable")
+}
+
+func (c *ContactTest) set(name, value string) error {
+	if !varName.MatchString(name) {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	if name == "lastCreatedID" || name == "uuid" {
+		return fmt.Errorf("%q is a built-in and cannot be set", name)
+	}
+	if c.vars == nil {
+		c.vars = make(map[string]string)
+	}
+	c.vars[name] = value
+	return nil
+}
+
+func (c *ContactTest) iSaveTheResponseFieldAs(ctx context.Context, path, name string) error {
+	v, err := c.field(path)
+	if err != nil {
+		return err
+	}
+	return c.set(name, jsonpath.Text(v))
+}
+
+func (c *ContactTest) iSetTheVariableTo(ctx context.Context, name, value string) error {
+	value, err := c.expand(value)
+	if err != nil {
+		return err
+	}
+	return c.set(name, value)
+}
+
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
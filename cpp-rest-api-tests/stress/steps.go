@@ -0,0 +1,72 @@
+package stress
+
+import (
+	"context"
+	"fmt"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/scenario"
+)
+
+// Steps exposes stress runs to godog scenarios.
+type Steps struct {
+	api    *client.Client
+	report *Report
+}
+
+func NewSteps(api *client.Client) *Steps {
+	return &Steps{api: api}
+}
+
+func (s *Steps) Register(ctx scenario.Context) {
+	ctx.Step(`^(\d+) clients concurrently create contacts$`, s.clientsConcurrentlyCreateContacts)
+	ctx.Step(`^(\d+) clients concurrently create (\d+) contacts? each$`, s.clientsConcurrentlyCreateContactsEach)
+	ctx.Step(`^(\d+) clients concurrently create, update and delete contacts$`, s.clientsConcurrentlyCreateUpdateAndDelete)
+	ctx.Step(`^(\d+) clients concurrently reset the store (\d+) times$`, s.clientsConcurrentlyResetTheStore)
+	ctx.Step(`^(\d+) contacts should have been created$`, s.contactsShouldHaveBeenCreated)
+	ctx.Step(`^no concurrency invariant should be violated$`, s.noConcurrencyInvariantShouldBeViolated)
+}
+
+// run uses the step's context, so cancelling the scenario stops the
+// clients.
+func (s *Steps) run(ctx context.Context, opts Options) error {
+	report, err := Run(ctx, s.api, opts)
+	if err != nil {
+		return err
+	}
+	s.report = report
+	return nil
+}
+
+func (s *Steps) clientsConcurrentlyCreateContacts(ctx context.Context, clients int) error {
+	return s.run(ctx, Options{Clients: clients, Creates: 1})
+}
+
+func (s *Steps) clientsConcurrentlyCreateContactsEach(ctx context.Context, clients, each int) error {
+	return s.run(ctx, Options{Clients: clients, Creates: each})
+}
+
+func (s *Steps) clientsConcurrentlyCreateUpdateAndDelete(ctx context.Context, clients int) error {
+	return s.run(ctx, Options{Clients: clients, Creates: 5, Updates: 10, Deletes: 2})
+}
+
+func (s *Steps) clientsConcurrentlyResetTheStore(ctx context.Context, clients, resets int) error {
+	return s.run(ctx, Options{Clients: clients, Creates: 2, Resets: resets})
+}
+
+func (s *Steps) contactsShouldHaveBeenCreated(count int) error {
+	if s.report == nil {
+		return fmt.Errorf("no concurrent run has happened in this scenario")
+	}
+	if len(s.report.Created) != count {
+		return fmt.Errorf("expected %d contacts to be created, got %d", count, len(s.report.Created))
+	}
+	return nil
+}
+
+func (s *Steps) noConcurrencyInvariantShouldBeViolated() error {
+	if s.report == nil {
+		return fmt.Errorf("no concurrent run has happened in this scenario")
+	}
+	return s.report.Err()
+}
@@ -0,0 +1,281 @@
+// Package stress fires parallel creates, updates, deletes and resets at the
+// contacts API and checks the invariants a correctly locked store keeps.
+// main.cpp serves with threads(4) and shares the records vector and
+// next_id without a mutex, so these are expected to fail there under load.
+package stress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cpp-rest-api-tests/client"
+)
+
+type Options struct {
+	// Clients is the number of goroutines issuing requests in parallel.
+	Clients int
+	// Creates, Updates and Deletes are per client. Each client only
+	// updates and deletes contacts it created, so the expected final
+	// state is well defined.
+	Creates int
+	Updates int
+	Deletes int
+	// Resets is the number of DELETE /reset calls raced against creates
+	// in a final phase. Zero skips that phase.
+	Resets int
+}
+
+// Report lists what happened and every broken invariant.
+type Report struct {
+	Created    []int
+	Deleted    []int
+	Violations []string
+	Duration   time.Duration
+}
+
+func (r *Report) violate(format string, args ...interface{}) {
+	r.Violations = append(r.Violations, fmt.Sprintf(format, args...))
+}
+
+// Err summarises the violations, or returns nil when there were none.
+func (r *Report) Err() error {
+	if len(r.Violations) == 0 {
+		return nil
+	}
+	const max = 20
+	lines := r.Violations
+	if len(lines) > max {
+		lines = append(lines[:max:max], fmt.Sprintf("... and %d more", len(r.Violations)-max))
+	}
+	return fmt.Errorf("%d invariant violation(s):\n  %s", len(r.Violations), strings.Join(lines, "\n  "))
+}
+
+// owned is what one client created and the last value it wrote.
+type owned struct {
+	contacts map[int]client.Contact
+	order    []int
+	deleted  map[int]bool
+}
+
+// Run resets the store and runs every phase in Options, recording invariant
+// violations in the report. The error is only for failures that stop the
+// run, such as the server being unreachable or ctx being cancelled.
+func Run(ctx context.Context, api *client.Client, opts Options) (*Report, error) {
+	if opts.Clients < 1 {
+		opts.Clients = 1
+	}
+	start := time.Now()
+	report := &Report{}
+	if err := api.Reset(ctx); err != nil {
+		return nil, fmt.Errorf("failed to reset before stress run: %v", err)
+	}
+
+	var mu sync.Mutex
+	state := make([]*owned, opts.Clients)
+	for i := range state {
+		state[i] = &owned{contacts: map[int]client.Contact{}, deleted: map[int]bool{}}
+	}
+
+	parallel(opts.Clients, func(n int) {
+		for i := 0; i < opts.Creates && ctx.Err() == nil; i++ {
+			want := client.Contact{FirstName: fmt.Sprintf("Client%d", n), LastName: fmt.Sprintf("Seq%d", i), Phone: fmt.Sprintf("555%07d", n*10000+i)}
+			created, err := api.Create(ctx, want)
+			mu.Lock()
+			if err != nil {
+				report.violate("client %d create %d failed: %v", n, i, err)
+				mu.Unlock()
+				continue
+			}
+			report.Created = append(report.Created, created.ID)
+			mu.Unlock()
+
+			s := state[n]
+			if len(s.order) > 0 && created.ID <= s.order[len(s.order)-1] {
+				mu.Lock()
+				report.violate("client %d got ID %d after ID %d: IDs are not monotonic", n, created.ID, s.order[len(s.order)-1])
+				mu.Unlock()
+			}
+			s.order = append(s.order, created.ID)
+			s.contacts[created.ID] = created
+
+			got, err := api.Get(ctx, created.ID)
+			mu.Lock()
+			switch {
+			case errors.Is(err, client.ErrNotFound):
+				report.violate("client %d: GET /records/%d returned 404 right after it was created", n, created.ID)
+			case err != nil:
+				report.violate("client %d: GET /records/%d failed: %v", n, created.ID, err)
+			case got.FirstName != want.FirstName || got.LastName != want.LastName:
+				report.violate("client %d: record %d reads back as %s %s, wrote %s %s", n, created.ID, got.FirstName, got.LastName, want.FirstName, want.LastName)
+			}
+			mu.Unlock()
+		}
+	})
+
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	checkUnique(report)
+
+	parallel(opts.Clients, func(n int) {
+		s := state[n]
+		if len(s.order) == 0 {
+			return
+		}
+		for i := 0; i < opts.Updates && ctx.Err() == nil; i++ {
+			id := s.order[i%len(s.order)]
+			city := fmt.Sprintf("City-%d-%d", n, i)
+			updated, err := api.Update(ctx, id, client.Fields{"city": city})
+			mu.Lock()
+			if err != nil {
+				report.violate("client %d update of %d failed: %v", n, id, err)
+			} else if updated.City != city {
+				report.violate("client %d: update of %d answered city %q, wrote %q", n, id, updated.City, city)
+			}
+			mu.Unlock()
+			c := s.contacts[id]
+			c.City = city
+			s.contacts[id] = c
+		}
+	})
+
+	parallel(opts.Clients, func(n int) {
+		s := state[n]
+		for i := 0; i < opts.Deletes && i < len(s.order) && ctx.Err() == nil; i++ {
+			id := s.order[i]
+			err := api.Delete(ctx, id)
+			mu.Lock()
+			if err != nil {
+				report.violate("client %d delete of %d failed: %v", n, id, err)
+			} else {
+				report.Deleted = append(report.Deleted, id)
+			}
+			mu.Unlock()
+			s.deleted[id] = true
+		}
+	})
+
+	if err := checkFinalState(ctx, api, state, report); err != nil {
+		return report, err
+	}
+
+	if opts.Resets > 0 {
+		if err := resetStorm(ctx, api, opts, report); err != nil {
+			return report, err
+		}
+	}
+
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+func parallel(clients int, fn func(n int)) {
+	var wg sync.WaitGroup
+	for n := 0; n < clients; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			fn(n)
+		}(n)
+	}
+	wg.Wait()
+}
+
+// checkUnique verifies that the IDs handed out since the reset are unique
+// and, with no resets or deletes in between, exactly 1..N.
+func checkUnique(report *Report) {
+	seen := map[int]bool{}
+	for _, id := range report.Created {
+		if seen[id] {
+			report.violate("ID %d was assigned to more than one contact", id)
+		}
+		seen[id] = true
+	}
+	ids := append([]int(nil), report.Created...)
+	sort.Ints(ids)
+	for i, id := range ids {
+		if id != i+1 {
+			report.violate("IDs after reset are not contiguous: position %d holds ID %d", i+1, id)
+			break
+		}
+	}
+}
+
+// checkFinalState compares GET /records with what the clients wrote.
+func checkFinalState(ctx context.Context, api *client.Client, state []*owned, report *Report) error {
+	all, err := api.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list records: %v", err)
+	}
+	actual := map[int]client.Contact{}
+	for _, c := range all {
+		actual[c.ID] = c
+	}
+
+	expected := 0
+	for _, s := range state {
+		for id, want := range s.contacts {
+			got, ok := actual[id]
+			switch {
+			case s.deleted[id] && ok:
+				report.violate("record %d was deleted but is still listed", id)
+			case s.deleted[id]:
+			case !ok:
+				report.violate("record %d is missing from GET /records", id)
+			case got != want:
+				report.violate("record %d lost a write: have %+v, want %+v", id, got, want)
+			}
+			if !s.deleted[id] {
+				expected++
+			}
+		}
+	}
+	if len(all) != expected {
+		report.violate("GET /records returned %d contacts, the model has %d", len(all), expected)
+	}
+	return nil
+}
+
+// resetStorm races resets against creates, then checks that one final
+// reset still leaves an empty store whose next ID is 1.
+func resetStorm(ctx context.Context, api *client.Client, opts Options, report *Report) error {
+	var mu sync.Mutex
+	parallel(opts.Clients, func(n int) {
+		for i := n; i < opts.Resets && ctx.Err() == nil; i += opts.Clients {
+			if err := api.Reset(ctx); err != nil {
+				mu.Lock()
+				report.violate("reset failed under load: %v", err)
+				mu.Unlock()
+			}
+			if _, err := api.Create(ctx, client.Contact{FirstName: "Storm"}); err != nil {
+				mu.Lock()
+				report.violate("create during reset storm failed: %v", err)
+				mu.Unlock()
+			}
+		}
+	})
+
+	if err := api.Reset(ctx); err != nil {
+		return fmt.Errorf("final reset failed: %v", err)
+	}
+	all, err := api.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list records: %v", err)
+	}
+	if len(all) != 0 {
+		report.violate("store holds %d contacts after the final reset", len(all))
+	}
+	created, err := api.Create(ctx, client.Contact{FirstName: "AfterStorm"})
+	if err != nil {
+		return fmt.Errorf("create after reset storm failed: %v", err)
+	}
+	if created.ID != 1 {
+		report.violate("first ID after the final reset is %d, want 1", created.ID)
+	}
+	return nil
+}
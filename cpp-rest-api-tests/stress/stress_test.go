@@ -0,0 +1,107 @@
+package stress
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/refapi"
+	"cpp-rest-api-tests/testenv"
+)
+
+var (
+	targetFlags = config.BindFlags(flag.CommandLine)
+	enabled     = flag.Bool("stress", false, "run the invariants under load against the target")
+	clients     = flag.Int("stress.clients", 16, "parallel clients")
+	creates     = flag.Int("stress.creates", 10, "creates per client")
+	updates     = flag.Int("stress.updates", 10, "updates per client")
+	deletes     = flag.Int("stress.deletes", 3, "deletes per client")
+	resets      = flag.Int("stress.resets", 20, "resets raced against creates")
+)
+
+var api *client.Client
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { api = env.API })
+}
+
+// TestInvariantsUnderLoad is opt-in like features/concurrency.feature:
+// main.cpp is expected to fail it, and its resets would wipe the store
+// under any other package testing the same server.
+func TestInvariantsUnderLoad(t *testing.T) {
+	if !*enabled {
+		t.Skip("stress runs are opt-in; run with -stress")
+	}
+	report, err := Run(context.Background(), api, Options{
+		Clients: *clients,
+		Creates: *creates,
+		Updates: *updates,
+		Deletes: *deletes,
+		Resets:  *resets,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%d creates, %d deletes in %s", len(report.Created), len(report.Deleted), report.Duration)
+}
+
+func TestRunStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ref := refapi.NewHandler()
+	var posts atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && posts.Add(1) == 10 {
+			cancel()
+		}
+		ref.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	report, err := Run(ctx, client.New(srv.URL, nil), Options{Clients: 4, Creates: 1000, Updates: 1000})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if n := posts.Load(); n > 20 {
+		t.Fatalf("%d creates were sent after the run was cancelled at 10", n)
+	}
+	if report == nil {
+		t.Fatal("no report for the cancelled run")
+	}
+}
+
+func TestDetectsDuplicateIDs(t *testing.T) {
+	// Every create claims ID 1, as a racy next_id_++ might.
+	ref := refapi.NewHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			rec := httptest.NewRecorder()
+			ref.ServeHTTP(rec, r)
+			body := rec.Body.String()
+			body = body[:strings.Index(body, `"id":`)+5] + "1" + body[strings.Index(body, `,"last_name"`):]
+			w.WriteHeader(rec.Code)
+			w.Write([]byte(body))
+			return
+		}
+		ref.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	report, err := Run(context.Background(), client.New(srv.URL, nil), Options{Clients: 4, Creates: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.Err(); err == nil || !strings.Contains(err.Error(), "ID 1 was assigned to more than one contact") {
+		t.Fatalf("expected a duplicate ID violation, got %v", err)
+	}
+}
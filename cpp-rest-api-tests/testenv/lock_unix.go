@@ -0,0 +1,36 @@
+//go:build unix
+
+package testenv
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// lock takes an exclusive lock on a file named after baseURL, waiting for
+// any other test binary that holds it. The lock goes with the process, so
+// a crashed package cannot leave it behind.
+func lock(baseURL string) (func(), error) {
+	name := baseURL
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		name = u.Host
+	}
+	name = strings.NewReplacer(":", "_", "/", "_").Replace(name)
+	path := filepath.Join(os.TempDir(), "contacts-api-"+name+".lock")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o666)
+	if err != nil {
+		return nil, fmt.Errorf("testenv: failed to open %s: %v", path, err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		fmt.Fprintf(os.Stderr, "testenv: waiting for another package testing %s\n", baseURL)
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("testenv: failed to lock %s: %v", path, err)
+		}
+	}
+	return func() { f.Close() }, nil
+}
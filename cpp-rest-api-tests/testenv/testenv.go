@@ -0,0 +1,68 @@
+// Package testenv starts the API a test binary runs against, from the
+// -contacts.* flags, for the packages whose tests talk to a live API.
+//
+// go test ./... runs packages in parallel, and every one of them resets the
+// store. A launched API (managed, reference, docker) is private to its
+// package, but a shared target such as local or ci is not: the packages
+// would wipe each other's records. Launch therefore holds a lock per shared
+// base URL for as long as the package runs, so packages that share a
+// target take turns, as they would with go test -p 1.
+package testenv
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"cpp-rest-api-tests/apiserver"
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+)
+
+// Env is the API the tests talk to. Server is nil for a shared target.
+type Env struct {
+	Config config.Config
+	Server *apiserver.Server
+	API    *client.Client
+}
+
+// Launch parses the command line if needed, then starts or locks the target
+// described by flags. It exits with a readable message when the target
+// cannot be used. Call the returned function when the tests are done.
+func Launch(flags *config.Flags) (Env, func()) {
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+	cfg := config.MustLoad(flags)
+	var unlock func()
+	if cfg.Launch == config.LaunchNone {
+		var err error
+		if unlock, err = lock(cfg.BaseURL); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	server, cfg, err := apiserver.Launch(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	env := Env{Config: cfg, Server: server, API: client.New(cfg.BaseURL, cfg.HTTPClient())}
+	return env, func() {
+		server.Stop()
+		if unlock != nil {
+			unlock()
+		}
+	}
+}
+
+// Main is TestMain for packages that only need the Env: it launches the
+// target, hands it to setup, runs the tests and exits.
+func Main(m *testing.M, flags *config.Flags, setup func(Env)) {
+	env, done := Launch(flags)
+	setup(env)
+	status := m.Run()
+	done()
+	os.Exit(status)
+}
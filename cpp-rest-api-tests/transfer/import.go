@@ -0,0 +1,126 @@
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"cpp-rest-api-tests/client"
+)
+
+type Options struct {
+	// DryRun validates the rows without creating anything.
+	DryRun bool
+	// Progress, when set, gets one line per row.
+	Progress io.Writer
+}
+
+// Summary is the outcome of an import.
+type Summary struct {
+	DryRun   bool       `json:"dry_run,omitempty"`
+	Rows     int        `json:"rows"`
+	Valid    int        `json:"valid"`
+	Created  []Created  `json:"created"`
+	Rejected []Rejected `json:"rejected,omitempty"`
+}
+
+type Created struct {
+	Line int `json:"line"`
+	ID   int `json:"id"`
+}
+
+type Rejected struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// IDs lists the created IDs in input order.
+func (s *Summary) IDs() []int {
+	ids := make([]int, len(s.Created))
+	for i, c := range s.Created {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+// Import creates the valid rows in order, one POST /records each. Rows
+// the server refuses are rejected like rows that failed to parse; an
+// error is returned only when ctx is done.
+func Import(ctx context.Context, api *client.Client, rows []Row, opts Options) (*Summary, error) {
+	s := &Summary{DryRun: opts.DryRun, Rows: len(rows), Created: []Created{}}
+	progress := func(format string, args ...interface{}) {
+		if opts.Progress != nil {
+			fmt.Fprintf(opts.Progress, format+"\n", args...)
+		}
+	}
+	for i, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return s, err
+		}
+		prefix := fmt.Sprintf("[%d/%d] line %d:", i+1, len(rows), row.Line)
+		if row.Err != nil {
+			s.Rejected = append(s.Rejected, Rejected{Line: row.Line, Reason: row.Err.Error()})
+			progress("%s rejected: %v", prefix, row.Err)
+			continue
+		}
+		s.Valid++
+		if opts.DryRun {
+			progress("%s ok: %s", prefix, describe(row.Contact))
+			continue
+		}
+		created, err := api.Create(ctx, row.Contact)
+		if err != nil {
+			if ctx.Err() != nil {
+				return s, ctx.Err()
+			}
+			s.Valid--
+			s.Rejected = append(s.Rejected, Rejected{Line: row.Line, Reason: err.Error()})
+			progress("%s rejected: %v", prefix, err)
+			continue
+		}
+		s.Created = append(s.Created, Created{Line: row.Line, ID: created.ID})
+		progress("%s created ID %d: %s", prefix, created.ID, describe(created))
+	}
+	return s, nil
+}
+
+func describe(c client.Contact) string {
+	if name := fullName(c); name != "" {
+		return name
+	}
+	if c.Email != "" {
+		return c.Email
+	}
+	if c.Phone != "" {
+		return c.Phone
+	}
+	return "(no name)"
+}
+
+// WriteText writes the counts, the created IDs and every rejected row.
+func (s *Summary) WriteText(w io.Writer) {
+	if s.DryRun {
+		fmt.Fprintf(w, "Dry run: %d rows, %d valid, %d rejected.\n", s.Rows, s.Valid, len(s.Rejected))
+	} else {
+		fmt.Fprintf(w, "Imported %d rows: %d created, %d rejected.\n", s.Rows, len(s.Created), len(s.Rejected))
+	}
+	if len(s.Created) > 0 {
+		ids := make([]string, len(s.Created))
+		for i, c := range s.Created {
+			ids[i] = fmt.Sprint(c.ID)
+		}
+		fmt.Fprintf(w, "Created IDs: %s\n", strings.Join(ids, ", "))
+	}
+	for _, r := range s.Rejected {
+		fmt.Fprintf(w, "Rejected line %d: %s\n", r.Line, r.Reason)
+	}
+}
+
+// WriteJSON writes the summary as indented JSON.
+func (s *Summary) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
@@ -0,0 +1,269 @@
+// Package transfer moves contacts in and out of the API as CSV, JSON Lines
+// (NDJSON) or vCard 4.0.
+//
+// Exports carry the field set of Record::to_json in main.cpp, in the same
+// order. Imports go through POST /records one row at a time; the id column
+// is ignored because the server assigns IDs. Columns in a CSV header or
+// keys in an NDJSON object that are not field names must be mapped, either
+// to a field or to Skip.
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cpp-rest-api-tests/client"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	VCard  Format = "vcard"
+)
+
+var Formats = []Format{CSV, NDJSON, VCard}
+
+// ParseFormat accepts a format name or one of its file extensions.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "csv":
+		return CSV, nil
+	case "ndjson", "jsonl":
+		return NDJSON, nil
+	case "vcard", "vcf":
+		return VCard, nil
+	}
+	return "", fmt.Errorf("unknown format %q (want csv, ndjson or vcard)", name)
+}
+
+// FormatFor guesses the format from a file name.
+func FormatFor(path string) (Format, error) {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return "", fmt.Errorf("%s: no extension to tell the format from", path)
+	}
+	return ParseFormat(ext)
+}
+
+// Fields are the JSON names of Record::to_json, in its order.
+var Fields = []string{
+	"id", "first_name", "middle_name", "last_name",
+	"street", "city", "state", "zip", "phone", "email",
+}
+
+// Skip as a mapping target drops the column.
+const Skip = "-"
+
+// Mapping maps source column names to field names or Skip. Names that are
+// not mapped are matched to fields case-insensitively, with spaces and
+// dashes read as underscores.
+type Mapping map[string]string
+
+// ParseMapping reads "column=field" pairs.
+func ParseMapping(pairs []string) (Mapping, error) {
+	m := Mapping{}
+	for _, pair := range pairs {
+		column, field, ok := strings.Cut(pair, "=")
+		if !ok || column == "" {
+			return nil, fmt.Errorf("mapping %q: want column=field", pair)
+		}
+		if field != Skip && !isField(field) {
+			return nil, fmt.Errorf("mapping %q: %q is not a field (want one of %s or %s)", pair, field, strings.Join(Fields, ", "), Skip)
+		}
+		m[column] = field
+	}
+	return m, nil
+}
+
+func (m Mapping) field(column string) (string, bool) {
+	if field, ok := m[column]; ok {
+		return field, true
+	}
+	name := strings.ToLower(strings.TrimSpace(column))
+	name = strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+	if isField(name) {
+		return name, true
+	}
+	return "", false
+}
+
+func isField(name string) bool {
+	for _, f := range Fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func get(c client.Contact, field string) string {
+	if field == "id" {
+		return strconv.Itoa(c.ID)
+	}
+	if f := c.Field(field); f != nil {
+		return *f
+	}
+	return ""
+}
+
+// Export writes contacts in format.
+func Export(w io.Writer, format Format, contacts []client.Contact) error {
+	switch format {
+	case CSV:
+		return writeCSV(w, contacts)
+	case NDJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, c := range contacts {
+			if err := enc.Encode(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	case VCard:
+		return writeVCards(w, contacts)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func writeCSV(w io.Writer, contacts []client.Contact) error {
+	cw := csv.NewWriter(w)
+	cw.Write(Fields)
+	row := make([]string, len(Fields))
+	for _, c := range contacts {
+		for i, f := range Fields {
+			row[i] = get(c, f)
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Row is one parsed input record. Line is where it starts in the input;
+// Err is why it cannot be imported.
+type Row struct {
+	Line    int
+	Contact client.Contact
+	Err     error
+}
+
+// Read parses every record in r. It fails only when the input as a whole
+// is unusable, such as a CSV header with unmapped columns; problems with
+// single records are left in Row.Err.
+func Read(r io.Reader, format Format, m Mapping) ([]Row, error) {
+	switch format {
+	case CSV:
+		return readCSV(r, m)
+	case NDJSON:
+		return readNDJSON(r, m)
+	case VCard:
+		return readVCards(r)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+func readCSV(r io.Reader, m Mapping) ([]Row, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("header: %v", err)
+	}
+	columns := make([]string, len(header))
+	var unknown []string
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		field, ok := m.field(name)
+		if !ok {
+			unknown = append(unknown, strconv.Quote(name))
+		}
+		columns[i] = field
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("header: unknown column(s) %s; map them to a field or to %s", strings.Join(unknown, ", "), Skip)
+	}
+
+	var rows []Row
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		var row Row
+		if err != nil {
+			pe, ok := err.(*csv.ParseError)
+			if !ok {
+				return nil, err
+			}
+			row.Line, row.Err = pe.StartLine, pe.Err
+			rows = append(rows, row)
+			continue
+		}
+		row.Line, _ = cr.FieldPos(0)
+		for i, value := range record {
+			row.Contact.Set(columns[i], value)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func readNDJSON(r io.Reader, m Mapping) ([]Row, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Row
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		row := Row{Line: i + 1}
+		row.Contact, row.Err = decodeObject(line, m)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func decodeObject(line string, m Mapping) (client.Contact, error) {
+	var c client.Contact
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &obj); err != nil {
+		return c, fmt.Errorf("not a JSON object: %v", err)
+	}
+	// Sorted so that the first problem reported does not vary.
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field, ok := m.field(key)
+		if !ok {
+			return c, fmt.Errorf("unknown key %q; map it to a field or to %s", key, Skip)
+		}
+		if field == Skip || field == "id" {
+			continue
+		}
+		switch v := obj[key].(type) {
+		case string:
+			c.Set(field, v)
+		case nil:
+		default:
+			return c, fmt.Errorf("%s: expected a string, got %v", key, v)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,206 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/contactgen"
+	"cpp-rest-api-tests/testenv"
+)
+
+var targetFlags = config.BindFlags(flag.CommandLine)
+
+var api *client.Client
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { api = env.API })
+}
+
+// awkward holds values each format has to escape.
+var awkward = []client.Contact{
+	{FirstName: "Zoë", MiddleName: "Q.", LastName: "O'Brien, Jr.", Street: "1 \"Main\" St; Apt 2", City: "Saint-Étienne", Zip: "01234", Phone: "+1 (555) 010-9999", Email: "zoe@example.com"},
+	{FirstName: `Back\slash`, Street: "line one\nline two", City: strings.Repeat("Springfield ", 10)},
+	{},
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	generated, err := contactgen.Generate(contactgen.Options{Seed: 22, Count: 25, EmptyMiddleName: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contacts := append(generated, awkward...)
+
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			if err := api.Reset(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Import(ctx, api, rowsOf(contacts), Options{}); err != nil {
+				t.Fatal(err)
+			}
+			before, err := api.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var exported bytes.Buffer
+			if err := Export(&exported, format, before); err != nil {
+				t.Fatal(err)
+			}
+			if err := api.Reset(ctx); err != nil {
+				t.Fatal(err)
+			}
+			rows, err := Read(bytes.NewReader(exported.Bytes()), format, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			summary, err := Import(ctx, api, rows, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(summary.Rejected) > 0 || len(summary.Created) != len(before) {
+				t.Fatalf("created %d of %d, rejected %+v", len(summary.Created), len(before), summary.Rejected)
+			}
+			after, err := api.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := withoutIDs(after), withoutIDs(before); !reflect.DeepEqual(got, want) {
+				for i := 0; i < len(got) && i < len(want); i++ {
+					if got[i] != want[i] {
+						t.Fatalf("contact %d changed in a %s round trip:\n got %+v\nwant %+v", i, format, got[i], want[i])
+					}
+				}
+				t.Fatalf("%s round trip returned %d contacts, want %d", format, len(got), len(want))
+			}
+		})
+	}
+	api.Reset(ctx)
+}
+
+func rowsOf(contacts []client.Contact) []Row {
+	rows := make([]Row, len(contacts))
+	for i, c := range contacts {
+		rows[i] = Row{Line: i + 1, Contact: c}
+	}
+	return rows
+}
+
+func withoutIDs(contacts []client.Contact) []client.Contact {
+	out := make([]client.Contact, len(contacts))
+	for i, c := range contacts {
+		c.ID = 0
+		out[i] = c
+	}
+	return out
+}
+
+func TestReadMapsAndRejects(t *testing.T) {
+	csvInput := "Given Name,Surname,Phone,Notes\n" +
+		"Ada,Lovelace,5550000001,first\n" +
+		"Grace,Hopper\n" +
+		"Alan,Turing,5550000003,\"unterminated\n"
+	if _, err := Read(strings.NewReader(csvInput), CSV, Mapping{"Given Name": "first_name", "Surname": "last_name"}); err == nil || !strings.Contains(err.Error(), `"Notes"`) {
+		t.Fatalf("unmapped column: %v", err)
+	}
+	rows, err := Read(strings.NewReader(csvInput), CSV, Mapping{"Given Name": "first_name", "Surname": "last_name", "Notes": Skip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows: %+v", len(rows), rows)
+	}
+	if rows[0].Err != nil || rows[0].Contact != (client.Contact{FirstName: "Ada", LastName: "Lovelace", Phone: "5550000001"}) || rows[0].Line != 2 {
+		t.Errorf("row 1 = %+v", rows[0])
+	}
+	if rows[1].Err == nil || rows[1].Line != 3 {
+		t.Errorf("short row = %+v", rows[1])
+	}
+	if rows[2].Err == nil || rows[2].Line != 4 {
+		t.Errorf("unterminated quote = %+v", rows[2])
+	}
+
+	ndjson := `{"id": 7, "first_name": "Ada", "Phone": "5550000001"}` + "\n\n" +
+		`{"first_name": 42}` + "\n" +
+		`{"nickname": "Bob"}` + "\n" +
+		`[1, 2]` + "\n"
+	rows, err = Read(strings.NewReader(ndjson), NDJSON, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0].Err != nil || rows[0].Contact != (client.Contact{FirstName: "Ada", Phone: "5550000001"}) {
+		t.Errorf("object = %+v", rows[0])
+	}
+	for i, want := range []string{"expected a string", `unknown key "nickname"`, "not a JSON object"} {
+		if row := rows[i+1]; row.Err == nil || !strings.Contains(row.Err.Error(), want) || row.Line != i+3 {
+			t.Errorf("row %d = %+v, want line %d and %q", i+2, row, i+3, want)
+		}
+	}
+}
+
+func TestReadVCards(t *testing.T) {
+	input := "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Ada Lovelace\r\nN:Lovelace;Ada;;;\r\n" +
+		"item1.TEL;TYPE=cell;VALUE=uri:tel:+44-20-5550\r\n 001\r\nEMAIL;TYPE=work:ada@example.com\r\n" +
+		"ADR;TYPE=home:;;12 St James\\, Square;London;;SW1;UK\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\r\nVERSION:3.0\r\nFN:Old Card\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Prince\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Cut Off\r\n"
+	rows, err := Read(strings.NewReader(input), VCard, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows: %+v", len(rows), rows)
+	}
+	want := client.Contact{FirstName: "Ada", LastName: "Lovelace", Street: "12 St James, Square", City: "London", Zip: "SW1", Phone: "+44-20-5550001", Email: "ada@example.com"}
+	if rows[0].Err != nil || rows[0].Contact != want {
+		t.Errorf("card 1 = %+v", rows[0])
+	}
+	if rows[1].Err == nil || rows[1].Line != 10 {
+		t.Errorf("vCard 3.0 = %+v", rows[1])
+	}
+	if rows[2].Err != nil || rows[2].Contact != (client.Contact{FirstName: "Prince"}) {
+		t.Errorf("FN only = %+v", rows[2])
+	}
+	if rows[3].Err == nil {
+		t.Errorf("missing END = %+v", rows[3])
+	}
+
+	var out bytes.Buffer
+	Export(&out, VCard, awkward[1:2])
+	for _, line := range strings.Split(out.String(), "\r\n") {
+		if len(line) > foldAt {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+	}
+}
+
+func TestDryRunCreatesNothing(t *testing.T) {
+	ctx := context.Background()
+	if err := api.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	rows := append(rowsOf(awkward), Row{Line: 9, Err: fmt.Errorf("bad row")})
+	var progress bytes.Buffer
+	s, err := Import(ctx, api, rows, Options{DryRun: true, Progress: &progress})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Valid != 3 || len(s.Created) != 0 || len(s.Rejected) != 1 || s.Rejected[0].Line != 9 {
+		t.Fatalf("summary = %+v", s)
+	}
+	if n := strings.Count(progress.String(), "\n"); n != 4 {
+		t.Errorf("%d progress lines:\n%s", n, progress.String())
+	}
+	if all, _ := api.List(ctx); len(all) != 0 {
+		t.Fatalf("dry run created %d contacts", len(all))
+	}
+}
@@ -0,0 +1,252 @@
+package transfer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"cpp-rest-api-tests/client"
+)
+
+// vCard 4.0 (RFC 6350) mapping:
+//
+//	N      last;first;middle;;
+//	ADR    ;;street;city;state;zip;
+//	TEL    phone, as text so that it round-trips unchanged
+//	EMAIL  email
+//	FN     the full name, required by the RFC and ignored on import
+//	       unless N is missing
+//	X-RECORD-ID  the API's id, informational only
+
+const foldAt = 75 // octets per line, excluding CRLF
+
+func writeVCards(w io.Writer, contacts []client.Contact) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range contacts {
+		writeLine(bw, "BEGIN:VCARD")
+		writeLine(bw, "VERSION:4.0")
+		writeLine(bw, "FN:"+escape(fullName(c)))
+		writeLine(bw, "N:"+compound(c.LastName, c.FirstName, c.MiddleName, "", ""))
+		if c.Street != "" || c.City != "" || c.State != "" || c.Zip != "" {
+			writeLine(bw, "ADR:"+compound("", "", c.Street, c.City, c.State, c.Zip, ""))
+		}
+		if c.Phone != "" {
+			writeLine(bw, "TEL;VALUE=text:"+escape(c.Phone))
+		}
+		if c.Email != "" {
+			writeLine(bw, "EMAIL:"+escape(c.Email))
+		}
+		if c.ID != 0 {
+			writeLine(bw, "X-RECORD-ID:"+strconv.Itoa(c.ID))
+		}
+		writeLine(bw, "END:VCARD")
+	}
+	return bw.Flush()
+}
+
+func fullName(c client.Contact) string {
+	var parts []string
+	for _, p := range []string{c.FirstName, c.MiddleName, c.LastName} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// writeLine folds a content line after foldAt octets without splitting a
+// UTF-8 sequence.
+func writeLine(w *bufio.Writer, line string) {
+	limit := foldAt
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = foldAt - 1 // the continuation starts with a space
+	}
+	w.WriteString(line + "\r\n")
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\r\n", `\n`, "\n", `\n`, ",", `\,`, ";", `\;`).Replace(s)
+}
+
+func compound(components ...string) string {
+	for i, c := range components {
+		components[i] = escape(c)
+	}
+	return strings.Join(components, ";")
+}
+
+func readVCards(r io.Reader) ([]Row, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Row
+	var card *Row
+	var hasN bool
+	var fn string
+	for _, l := range lines {
+		name, params, value, ok := splitLine(l.text)
+		if !ok {
+			if card != nil && card.Err == nil {
+				card.Err = fmt.Errorf("line %d: malformed content line", l.number)
+			}
+			continue
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCARD"):
+			if card != nil {
+				card.Err = fmt.Errorf("missing END:VCARD")
+				rows = append(rows, *card)
+			}
+			card, hasN, fn = &Row{Line: l.number}, false, ""
+		case card == nil:
+			// Text between cards is not ours to judge.
+		case name == "END" && strings.EqualFold(value, "VCARD"):
+			if !hasN && card.Err == nil {
+				card.Contact.FirstName = fn
+			}
+			rows = append(rows, *card)
+			card = nil
+		case name == "VERSION":
+			if value != "4.0" && card.Err == nil {
+				card.Err = fmt.Errorf("vCard version %s, want 4.0", value)
+			}
+		case name == "FN":
+			fn = unescape(value)
+		case name == "N":
+			hasN = true
+			n := components(value, 3)
+			card.Contact.LastName, card.Contact.FirstName, card.Contact.MiddleName = n[0], n[1], n[2]
+		case name == "ADR" && card.Contact.Street == "" && card.Contact.City == "" && card.Contact.State == "" && card.Contact.Zip == "":
+			adr := components(value, 6)
+			card.Contact.Street, card.Contact.City, card.Contact.State, card.Contact.Zip = adr[2], adr[3], adr[4], adr[5]
+		case name == "TEL" && card.Contact.Phone == "":
+			phone := unescape(value)
+			if !strings.EqualFold(params["VALUE"], "text") && len(phone) >= 4 && strings.EqualFold(phone[:4], "tel:") {
+				phone = phone[4:]
+			}
+			card.Contact.Phone = phone
+		case name == "EMAIL" && card.Contact.Email == "":
+			card.Contact.Email = unescape(value)
+		}
+	}
+	if card != nil {
+		card.Err = fmt.Errorf("missing END:VCARD")
+		rows = append(rows, *card)
+	}
+	return rows, nil
+}
+
+type contentLine struct {
+	number int
+	text   string
+}
+
+// unfold joins continuation lines, which start with a space or a tab, to
+// the line before them.
+func unfold(r io.Reader) ([]contentLine, error) {
+	var lines []contentLine
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	n := 0
+	for sc.Scan() {
+		n++
+		text := strings.TrimSuffix(sc.Text(), "\r")
+		if n == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		lines = append(lines, contentLine{number: n, text: text})
+	}
+	return lines, sc.Err()
+}
+
+// splitLine splits "[group.]NAME[;PARAM=VALUE...]:value". Names and
+// parameter names come back upper-cased.
+func splitLine(line string) (name string, params map[string]string, value string, ok bool) {
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return "", nil, "", false
+	}
+	parts := strings.Split(line[:colon], ";")
+	name = strings.ToUpper(parts[0])
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+	params = map[string]string{}
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return name, params, line[colon+1:], true
+}
+
+// components splits a structured value on unescaped semicolons into at
+// least n unescaped components.
+func components(value string, n int) []string {
+	var out []string
+	var cur strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value):
+			cur.WriteByte(value[i])
+			cur.WriteByte(value[i+1])
+			i++
+		case value[i] == ';':
+			out = append(out, unescape(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(value[i])
+		}
+	}
+	out = append(out, unescape(cur.String()))
+	for len(out) < n {
+		out = append(out, "")
+	}
+	return out
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
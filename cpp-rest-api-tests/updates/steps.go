@@ -0,0 +1,171 @@
+package updates
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cucumber/godog"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/scenario"
+)
+
+// Steps exposes the update cases to godog scenarios. mergePatch is the
+// merge patch switch from package config; without it the PATCH scenarios
+// are skipped. A Steps is made per scenario, so the contact it updates is
+// the scenario's own.
+type Steps struct {
+	api        *client.Client
+	mergePatch bool
+
+	id   int
+	last *Outcome
+}
+
+func NewSteps(api *client.Client, mergePatch bool) *Steps {
+	return &Steps{api: api, mergePatch: mergePatch}
+}
+
+func (s *Steps) Register(ctx scenario.Context) {
+	ctx.Step(`^the API supports JSON Merge Patch$`, s.theAPISupportsMergePatch)
+	ctx.Step(`^a contact with every field set exists$`, s.aContactWithEveryFieldSetExists)
+	ctx.Step(`^I update it with (PUT|PATCH):$`, s.iUpdateItWith)
+	ctx.Step(`^the update status should be (\d+)$`, s.theUpdateStatusShouldBe)
+	ctx.Step(`^the stored contact should be unchanged$`, s.theStoredContactShouldBeUnchanged)
+	ctx.Step(`^the stored contact should differ only in:$`, s.theStoredContactShouldDifferOnlyIn)
+	ctx.Step(`^the contact should keep its ID$`, s.theContactShouldKeepItsID)
+	ctx.Step(`^every "([^"]*)" PUT update case should hold$`, s.everyRulePUTCaseShouldHold)
+	ctx.Step(`^every PUT update case should hold$`, s.everyPUTCaseShouldHold)
+	ctx.Step(`^every merge patch case should hold$`, s.everyMergePatchCaseShouldHold)
+}
+
+func (s *Steps) theAPISupportsMergePatch() error {
+	if !s.mergePatch {
+		return fmt.Errorf("%w: merge patch checks are off; set CONTACTS_MERGE_PATCH=true or -contacts.merge-patch=true once PATCH /records/{id} exists", godog.ErrSkip)
+	}
+	return nil
+}
+
+func (s *Steps) aContactWithEveryFieldSetExists() error {
+	ctx := context.Background()
+	if err := s.api.Reset(ctx); err != nil {
+		return err
+	}
+	created, err := s.api.Create(ctx, Base)
+	if err != nil {
+		return err
+	}
+	s.id, s.last = created.ID, nil
+	return nil
+}
+
+func (s *Steps) iUpdateItWith(method string, body *godog.DocString) error {
+	if s.id == 0 {
+		return fmt.Errorf("no contact to update; use \"a contact with every field set exists\" first")
+	}
+	o, err := Send(context.Background(), s.api, method, s.id, body.Content)
+	if err != nil {
+		return err
+	}
+	s.last = o
+	return nil
+}
+
+func (s *Steps) outcome() (*Outcome, error) {
+	if s.last == nil {
+		return nil, fmt.Errorf("no update has been sent")
+	}
+	return s.last, nil
+}
+
+func (s *Steps) theUpdateStatusShouldBe(status int) error {
+	o, err := s.outcome()
+	if err != nil {
+		return err
+	}
+	if o.Status != status {
+		return fmt.Errorf("status %d, want %d: %s", o.Status, status, strings.TrimSpace(string(o.Body)))
+	}
+	return nil
+}
+
+func (s *Steps) theStoredContactShouldBeUnchanged() error {
+	return s.storedShouldBe(nil)
+}
+
+// theStoredContactShouldDifferOnlyIn reads a table with "field" and
+// "value" columns; an empty value cell means the field was cleared.
+func (s *Steps) theStoredContactShouldDifferOnlyIn(table *godog.Table) error {
+	if len(table.Rows) < 2 || len(table.Rows[0].Cells) != 2 ||
+		table.Rows[0].Cells[0].Value != "field" || table.Rows[0].Cells[1].Value != "value" {
+		return fmt.Errorf(`expected a table with "field" and "value" columns`)
+	}
+	changed := client.Fields{}
+	for _, row := range table.Rows[1:] {
+		name := row.Cells[0].Value
+		if Base.Field(name) == nil {
+			return fmt.Errorf("unknown field %q", name)
+		}
+		changed[name] = row.Cells[1].Value
+	}
+	return s.storedShouldBe(changed)
+}
+
+func (s *Steps) storedShouldBe(changed client.Fields) error {
+	o, err := s.outcome()
+	if err != nil {
+		return err
+	}
+	want := changed.Apply(Base)
+	want.ID = o.ID
+	if diffs := Diff(want, o.Stored); len(diffs) > 0 {
+		return fmt.Errorf("stored contact differs: %s", strings.Join(diffs, "; "))
+	}
+	return nil
+}
+
+func (s *Steps) theContactShouldKeepItsID() error {
+	o, err := s.outcome()
+	if err != nil {
+		return err
+	}
+	if o.Stored.ID != o.ID {
+		return fmt.Errorf("contact %d now has ID %d", o.ID, o.Stored.ID)
+	}
+	if o.Contacts != 1 {
+		return fmt.Errorf("%d contacts in the store, want 1", o.Contacts)
+	}
+	return nil
+}
+
+func (s *Steps) everyRulePUTCaseShouldHold(rule string) error {
+	cases := Cases(PUT, rule)
+	if len(cases) == 0 {
+		return fmt.Errorf("no update rule %q", rule)
+	}
+	return s.check(http.MethodPut, cases)
+}
+
+func (s *Steps) everyPUTCaseShouldHold() error {
+	return s.check(http.MethodPut, PUT)
+}
+
+func (s *Steps) everyMergePatchCaseShouldHold() error {
+	if err := s.theAPISupportsMergePatch(); err != nil {
+		return err
+	}
+	return s.check(http.MethodPatch, MergePatch)
+}
+
+func (s *Steps) check(method string, cases []Case) error {
+	diffs, err := Run(context.Background(), s.api, method, cases)
+	if err != nil {
+		return err
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("%d update(s) ended differently:\n  %s", len(diffs), strings.Join(diffs, "\n  "))
+	}
+	return nil
+}
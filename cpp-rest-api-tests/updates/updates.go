@@ -0,0 +1,251 @@
+// Package updates pins down what PUT /records/{id} does with the fields it
+// is sent, and what a PATCH route would have to do to be a JSON Merge
+// Patch (RFC 7396).
+//
+// ApiHandler::update reads every field with body.value(field, existing),
+// so PUT is a merge: fields left out keep their value, "" clears one, and
+// keys it does not know, id among them, are ignored. A field that is not a
+// string throws a type error and gets 400, but only after the fields
+// before it in Record order were assigned, so a failed PUT can still
+// change the contact. A body that is not a JSON object gets 400 unchanged.
+//
+// main.cpp has no PATCH route. MergePatch describes one that follows
+// RFC 7396: null clears a field, and any error leaves the contact as it
+// was. Its cases run only when the merge patch switch in package config is
+// on.
+package updates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"cpp-rest-api-tests/client"
+)
+
+// MergePatchType is the media type RFC 7396 registers for patches.
+const MergePatchType = "application/merge-patch+json"
+
+// Base is the contact every case starts from. Every field is set, so a
+// field that changes or clears is visible.
+var Base = client.Contact{
+	FirstName:  "Ada",
+	MiddleName: "King",
+	LastName:   "Lovelace",
+	Street:     "12 St James Square",
+	City:       "London",
+	State:      "LDN",
+	Zip:        "SW1Y",
+	Phone:      "5551234567",
+	Email:      "ada@example.com",
+}
+
+// Fields are the JSON names of the contact fields in Record order, the
+// order ApiHandler::update assigns them in.
+var Fields = client.FieldNames
+
+// Rules, in the order the case tables cover them.
+const (
+	RuleMerge   = "merge"
+	RuleClear   = "clear"
+	RuleType    = "type"
+	RuleUnknown = "unknown"
+	RuleID      = "id"
+	RuleBody    = "body"
+)
+
+// Case is one request body, the status it gets and the fields of Base it
+// leaves changed.
+type Case struct {
+	Rule    string
+	Body    string
+	Status  int
+	Changed client.Fields
+}
+
+func (c Case) String() string { return c.Rule + ": " + c.Body }
+
+// Want is Base with the case's changes applied.
+func (c Case) Want() client.Contact {
+	return c.Changed.Apply(Base)
+}
+
+// PUT lists the cases for PUT /records/{id} as main.cpp answers them.
+var PUT = append(mergeCases(), []Case{
+	{RuleClear, `{"middle_name":""}`, 200, client.Fields{"middle_name": ""}},
+	{RuleClear, `{"first_name":"","email":""}`, 200, client.Fields{"first_name": "", "email": ""}},
+	{RuleClear, `{"city":" "}`, 200, client.Fields{"city": " "}},
+
+	{RuleType, `{"phone":null}`, 400, nil},
+	{RuleType, `{"phone":5551234567}`, 400, nil},
+	{RuleType, `{"phone":true}`, 400, nil},
+	{RuleType, `{"phone":["555"]}`, 400, nil},
+	{RuleType, `{"phone":{"home":"555"}}`, 400, nil},
+	// Fields are assigned in Record order, whatever the order of the body,
+	// and a type error stops there.
+	{RuleType, `{"first_name":"Grace","phone":null,"email":"grace@example.com"}`, 400, client.Fields{"first_name": "Grace"}},
+	{RuleType, `{"email":"grace@example.com","zip":5,"first_name":"Grace"}`, 400, client.Fields{"first_name": "Grace"}},
+	{RuleType, `{"first_name":null,"last_name":"Hopper"}`, 400, nil},
+
+	{RuleUnknown, `{"nickname":"Countess"}`, 200, nil},
+	{RuleUnknown, `{"nickname":5,"city":"Paris"}`, 200, client.Fields{"city": "Paris"}},
+	{RuleUnknown, `{"First_Name":"Grace","CITY":"Paris"}`, 200, nil},
+
+	{RuleID, `{"id":99}`, 200, nil},
+	{RuleID, `{"id":99,"city":"Paris"}`, 200, client.Fields{"city": "Paris"}},
+	{RuleID, `{"id":"abc"}`, 200, nil},
+	{RuleID, `{"id":null}`, 200, nil},
+
+	{RuleBody, `{}`, 200, nil},
+	{RuleBody, `[]`, 400, nil},
+	{RuleBody, `"Ada"`, 400, nil},
+	{RuleBody, `null`, 400, nil},
+	{RuleBody, `not json`, 400, nil},
+	{RuleBody, ``, 400, nil},
+	{RuleBody, `{"city":"Paris"} {"city":"Rome"}`, 400, nil},
+}...)
+
+// MergePatch lists the cases a PATCH /records/{id} route must answer to
+// implement RFC 7396 on a contact. The contact has a fixed set of fields,
+// so removing one with null leaves it empty, and a patch that would make
+// the contact something other than a contact is refused as a whole.
+var MergePatch = append(mergeCases(), []Case{
+	{RuleClear, `{"middle_name":""}`, 200, client.Fields{"middle_name": ""}},
+	{RuleClear, `{"middle_name":null}`, 200, client.Fields{"middle_name": ""}},
+	{RuleClear, `{"first_name":"Grace","phone":null}`, 200, client.Fields{"first_name": "Grace", "phone": ""}},
+
+	{RuleType, `{"phone":5551234567}`, 400, nil},
+	{RuleType, `{"phone":{"home":"555"}}`, 400, nil},
+	{RuleType, `{"first_name":"Grace","phone":true}`, 400, nil},
+
+	{RuleUnknown, `{"nickname":"Countess"}`, 200, nil},
+	{RuleUnknown, `{"nickname":null,"city":"Paris"}`, 200, client.Fields{"city": "Paris"}},
+
+	{RuleID, `{"id":99}`, 200, nil},
+	{RuleID, `{"id":null,"city":"Paris"}`, 200, client.Fields{"city": "Paris"}},
+
+	{RuleBody, `{}`, 200, nil},
+	{RuleBody, `[]`, 400, nil},
+	{RuleBody, `null`, 400, nil},
+	{RuleBody, `not json`, 400, nil},
+}...)
+
+// mergeCases changes each field alone, then several at once.
+func mergeCases() []Case {
+	var cases []Case
+	for _, f := range Fields {
+		cases = append(cases, Case{RuleMerge, fmt.Sprintf(`{%q:"Changed"}`, f), 200, client.Fields{f: "Changed"}})
+	}
+	return append(cases, Case{RuleMerge, `{"city":"Paris","zip":"75001","state":""}`, 200, client.Fields{"city": "Paris", "zip": "75001", "state": ""}})
+}
+
+// Cases returns the cases in table for rule, or all of them for "".
+func Cases(table []Case, rule string) []Case {
+	var out []Case
+	for _, c := range table {
+		if rule == "" || c.Rule == rule {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Outcome is what one update did.
+type Outcome struct {
+	ID       int
+	Status   int
+	Body     []byte
+	Stored   client.Contact
+	Contacts int // in the store afterwards
+}
+
+// Apply resets the store, creates Base, sends body to /records/{id} with
+// method and reads the contact back.
+func Apply(ctx context.Context, api *client.Client, method, body string) (*Outcome, error) {
+	if err := api.Reset(ctx); err != nil {
+		return nil, err
+	}
+	created, err := api.Create(ctx, Base)
+	if err != nil {
+		return nil, err
+	}
+	return Send(ctx, api, method, created.ID, body)
+}
+
+// Send sends body to /records/{id} and reads the contact back.
+func Send(ctx context.Context, api *client.Client, method string, id int, body string) (*Outcome, error) {
+	contentType := "application/json"
+	if method == http.MethodPatch {
+		contentType = MergePatchType
+	}
+	resp, err := api.DoWithHeaders(ctx, method, fmt.Sprintf("/records/%d", id), map[string]string{"Content-Type": contentType}, []byte(body))
+	if err != nil {
+		return nil, err
+	}
+	o := &Outcome{ID: id, Status: resp.StatusCode, Body: resp.Body}
+	all, err := api.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o.Contacts = len(all)
+	if o.Stored, err = api.Get(ctx, id); err != nil {
+		return nil, fmt.Errorf("contact %d after %s: %v", id, method, err)
+	}
+	return o, nil
+}
+
+// Check compares an outcome with the status and contact the case expects.
+// A 200 must also answer with the stored contact.
+func (o *Outcome) Check(status int, want client.Contact) []string {
+	var problems []string
+	want.ID = o.ID
+	if o.Status != status {
+		problems = append(problems, fmt.Sprintf("status %d, want %d (%s)", o.Status, status, strings.TrimSpace(string(o.Body))))
+	}
+	if o.Contacts != 1 {
+		problems = append(problems, fmt.Sprintf("%d contacts in the store, want 1", o.Contacts))
+	}
+	problems = append(problems, Diff(want, o.Stored)...)
+	if o.Status == http.StatusOK {
+		var answered client.Contact
+		if err := json.Unmarshal(o.Body, &answered); err != nil {
+			problems = append(problems, fmt.Sprintf("200 body is not a contact: %v", err))
+		} else if answered != o.Stored {
+			problems = append(problems, fmt.Sprintf("answered %+v but stored %+v", answered, o.Stored))
+		}
+	}
+	return problems
+}
+
+// Diff lists the fields of got that differ from want, ID included.
+func Diff(want, got client.Contact) []string {
+	var diffs []string
+	if got.ID != want.ID {
+		diffs = append(diffs, fmt.Sprintf("id is %d, want %d", got.ID, want.ID))
+	}
+	w, g := want.Fields(), got.Fields()
+	for _, f := range Fields {
+		if w[f] != g[f] {
+			diffs = append(diffs, fmt.Sprintf("%s is %q, want %q", f, g[f], w[f]))
+		}
+	}
+	return diffs
+}
+
+// Run applies every case with method and describes each one that did not
+// end as expected.
+func Run(ctx context.Context, api *client.Client, method string, cases []Case) ([]string, error) {
+	var diffs []string
+	for _, c := range cases {
+		o, err := Apply(ctx, api, method, c.Body)
+		if err != nil {
+			return diffs, fmt.Errorf("%s %s: %v", method, c, err)
+		}
+		if problems := o.Check(c.Status, c.Want()); len(problems) > 0 {
+			diffs = append(diffs, fmt.Sprintf("%s %s: %s", method, c, strings.Join(problems, "; ")))
+		}
+	}
+	return diffs, nil
+}
@@ -0,0 +1,157 @@
+package updates
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cpp-rest-api-tests/client"
+	"cpp-rest-api-tests/config"
+	"cpp-rest-api-tests/refapi"
+	"cpp-rest-api-tests/testenv"
+)
+
+var targetFlags = config.BindFlags(flag.CommandLine)
+
+var (
+	api *client.Client
+	cfg config.Config
+)
+
+func TestMain(m *testing.M) {
+	testenv.Main(m, targetFlags, func(env testenv.Env) { cfg, api = env.Config, env.API })
+}
+
+func TestPUT(t *testing.T) {
+	run(t, api, http.MethodPut, PUT)
+}
+
+func TestMergePatch(t *testing.T) {
+	if !cfg.MergePatch {
+		t.Skip("merge patch checks are off; set -contacts.merge-patch=true")
+	}
+	run(t, api, http.MethodPatch, MergePatch)
+}
+
+func run(t *testing.T, api *client.Client, method string, cases []Case) {
+	ctx := context.Background()
+	defer api.Reset(ctx)
+	for _, c := range cases {
+		c := c
+		t.Run(c.Rule+"/"+c.Body, func(t *testing.T) {
+			o, err := Apply(ctx, api, method, c.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, problem := range o.Check(c.Status, c.Want()) {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+// Every rule has cases in both tables.
+func TestTablesCoverEveryRule(t *testing.T) {
+	rules := []string{RuleMerge, RuleClear, RuleType, RuleUnknown, RuleID, RuleBody}
+	for name, table := range map[string][]Case{"PUT": PUT, "MergePatch": MergePatch} {
+		total := 0
+		for _, rule := range rules {
+			n := len(Cases(table, rule))
+			if n == 0 {
+				t.Errorf("%s: rule %q has no cases", name, rule)
+			}
+			total += n
+		}
+		if total != len(table) {
+			t.Errorf("%s: %d of %d cases are under an unknown rule", name, len(table)-total, len(table))
+		}
+	}
+}
+
+// mergePatchServer serves refapi with a PATCH /records/{id} route built on
+// its GET and PUT. When atomic is false the patch goes straight to PUT, so
+// null is a type error and a failed patch keeps the fields before it.
+func mergePatchServer(atomic bool) *httptest.Server {
+	ref := refapi.NewHandler()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			ref.ServeHTTP(w, r)
+			return
+		}
+		r.Method = http.MethodPut
+		if !atomic {
+			ref.ServeHTTP(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var patch map[string]json.RawMessage
+		if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+			http.Error(w, `{"error":"Invalid JSON"}`, http.StatusBadRequest)
+			return
+		}
+		get := httptest.NewRecorder()
+		ref.ServeHTTP(get, httptest.NewRequest(http.MethodGet, r.URL.Path, nil))
+		if get.Code != http.StatusOK {
+			w.WriteHeader(get.Code)
+			w.Write(get.Body.Bytes())
+			return
+		}
+		merged := client.Fields{}
+		for _, f := range Fields {
+			raw, ok := patch[f]
+			if !ok {
+				continue
+			}
+			var v *string
+			if err := json.Unmarshal(raw, &v); err != nil {
+				http.Error(w, `{"error":"Invalid JSON"}`, http.StatusBadRequest)
+				return
+			}
+			if v == nil {
+				merged[f] = ""
+			} else {
+				merged[f] = *v
+			}
+		}
+		put, _ := json.Marshal(merged)
+		r.Body = io.NopCloser(bytes.NewReader(put))
+		ref.ServeHTTP(w, r)
+	}))
+}
+
+// A PATCH route that follows RFC 7396 passes every merge patch case.
+func TestMergePatchOnAConformingRoute(t *testing.T) {
+	srv := mergePatchServer(true)
+	defer srv.Close()
+	run(t, client.New(srv.URL, nil), http.MethodPatch, MergePatch)
+}
+
+// Forwarding PATCH to the PUT handler is not a merge patch: null is
+// refused, and a type error leaves the fields before it changed.
+func TestRunNamesTheBrokenCases(t *testing.T) {
+	srv := mergePatchServer(false)
+	defer srv.Close()
+
+	diffs, err := Run(context.Background(), client.New(srv.URL, nil), http.MethodPatch, MergePatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`PATCH clear: {"middle_name":null}`,
+		`PATCH clear: {"first_name":"Grace","phone":null}`,
+		`PATCH type: {"first_name":"Grace","phone":true}`,
+	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d[:strings.Index(d, "}: ")+1])
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("diffs:\n%s\nwant cases:\n%s", strings.Join(diffs, "\n"), strings.Join(want, "\n"))
+	}
+}
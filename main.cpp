@@ -6,6 +6,7 @@
 #include <algorithm>
 #include <iostream>
 #include <sstream>
+#include <cstdlib>
 #include <unordered_map>
 
 using namespace Pistache;
@@ -216,14 +217,22 @@ private:
     int& next_id_;
 };
 
-int main() {
+int main(int argc, char* argv[]) {
     std::vector<Record> records;
     int next_id = 1;
 
-    std::cout << "Starting API server on http://localhost:8080" << std::endl;
+    // Port comes from the first argument, then $PORT, defaulting to 8080
+    uint16_t port = 8080;
+    if (argc > 1) {
+        port = static_cast<uint16_t>(std::stoi(argv[1]));
+    } else if (const char* env_port = std::getenv("PORT")) {
+        port = static_cast<uint16_t>(std::stoi(env_port));
+    }
+
+    std::cout << "Starting API server on http://localhost:" << port << std::endl;
 
     // Initialize Pistache server
-    Http::Endpoint server(Address(Ipv4::any(), Port(8080)));
+    Http::Endpoint server(Address(Ipv4::any(), Port(port)));
     auto opts = Http::Endpoint::options().threads(4);
     server.init(opts);
 